@@ -0,0 +1,141 @@
+package propl
+
+import (
+	"bytes"
+	"cmp"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// comparableValue is the value a field compares as. Unset scalars compare as their
+// default value, and unset messages as an invalid (absent) value.
+func comparableValue(f *fieldData) protoreflect.Value {
+	if f == nil {
+		return protoreflect.Value{}
+	}
+	if f.s() {
+		return f.fv()
+	}
+	if d := f.d(); d != nil && !d.IsList() && !d.IsMap() && d.Message() == nil {
+		return d.Default()
+	}
+	return protoreflect.Value{}
+}
+
+// valuesEqual compares two field values. Absent values are only equal to each other.
+func valuesEqual(a, b protoreflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if c, ok := compareValues(a, b); ok {
+		return c == 0
+	}
+	switch av := a.Interface().(type) {
+	case protoreflect.Message:
+		bv, ok := b.Interface().(protoreflect.Message)
+		return ok && proto.Equal(av.Interface(), bv.Interface())
+	case protoreflect.List:
+		bv, ok := b.Interface().(protoreflect.List)
+		if !ok || av.Len() != bv.Len() {
+			return false
+		}
+		for i := 0; i < av.Len(); i++ {
+			if !valuesEqual(av.Get(i), bv.Get(i)) {
+				return false
+			}
+		}
+		return true
+	case protoreflect.Map:
+		bv, ok := b.Interface().(protoreflect.Map)
+		if !ok || av.Len() != bv.Len() {
+			return false
+		}
+		equal := true
+		av.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			equal = bv.Has(k) && valuesEqual(v, bv.Get(k))
+			return equal
+		})
+		return equal
+	}
+	return false
+}
+
+// compareValues orders two field values of the same kind. Well-known timestamps,
+// durations and wrappers are ordered by the value they represent. ok is false
+// when the values can't be ordered.
+func compareValues(a, b protoreflect.Value) (c int, ok bool) {
+	if !a.IsValid() || !b.IsValid() {
+		return 0, false
+	}
+	switch av := a.Interface().(type) {
+	case bool:
+		bv, ok := b.Interface().(bool)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case av == bv:
+			return 0, true
+		case !av:
+			return -1, true
+		default:
+			return 1, true
+		}
+	case int32:
+		bv, ok := b.Interface().(int32)
+		return cmp.Compare(av, bv), ok
+	case int64:
+		bv, ok := b.Interface().(int64)
+		return cmp.Compare(av, bv), ok
+	case uint32:
+		bv, ok := b.Interface().(uint32)
+		return cmp.Compare(av, bv), ok
+	case uint64:
+		bv, ok := b.Interface().(uint64)
+		return cmp.Compare(av, bv), ok
+	case float32:
+		bv, ok := b.Interface().(float32)
+		return cmp.Compare(av, bv), ok
+	case float64:
+		bv, ok := b.Interface().(float64)
+		return cmp.Compare(av, bv), ok
+	case string:
+		bv, ok := b.Interface().(string)
+		return cmp.Compare(av, bv), ok
+	case []byte:
+		bv, ok := b.Interface().([]byte)
+		return bytes.Compare(av, bv), ok
+	case protoreflect.EnumNumber:
+		bv, ok := b.Interface().(protoreflect.EnumNumber)
+		return cmp.Compare(av, bv), ok
+	case protoreflect.Message:
+		bv, ok := b.Interface().(protoreflect.Message)
+		if !ok || av.Descriptor().FullName() != bv.Descriptor().FullName() {
+			return 0, false
+		}
+		return compareWellKnown(av, bv)
+	}
+	return 0, false
+}
+
+// compareWellKnown orders well-known messages that represent a single value.
+func compareWellKnown(a, b protoreflect.Message) (int, bool) {
+	fields := a.Descriptor().Fields()
+	switch a.Descriptor().FullName() {
+	case "google.protobuf.Timestamp", "google.protobuf.Duration":
+		seconds, nanos := fields.ByNumber(1), fields.ByNumber(2)
+		if c := cmp.Compare(a.Get(seconds).Int(), b.Get(seconds).Int()); c != 0 {
+			return c, true
+		}
+		return cmp.Compare(a.Get(nanos).Int(), b.Get(nanos).Int()), true
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue",
+		"google.protobuf.Int64Value", "google.protobuf.UInt64Value",
+		"google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.BoolValue", "google.protobuf.StringValue",
+		"google.protobuf.BytesValue":
+		value := fields.ByNumber(1)
+		return compareValues(a.Get(value), b.Get(value))
+	}
+	return 0, false
+}
@@ -0,0 +1,53 @@
+package propl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCompareValues(t *testing.T) {
+	msg := func(m proto.Message) protoreflect.Value {
+		return protoreflect.ValueOfMessage(m.ProtoReflect())
+	}
+	now := time.Now()
+
+	t.Run("it should order well-known types", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			a, b protoreflect.Value
+		}{
+			"timestamp": {msg(timestamppb.New(now)), msg(timestamppb.New(now.Add(time.Nanosecond)))},
+			"duration":  {msg(durationpb.New(time.Second)), msg(durationpb.New(time.Minute))},
+			"wrapper":   {msg(wrapperspb.Int64(-1)), msg(wrapperspb.Int64(1))},
+			"scalar":    {protoreflect.ValueOfUint32(1), protoreflect.ValueOfUint32(2)},
+			"enum":      {protoreflect.ValueOfEnum(0), protoreflect.ValueOfEnum(2)},
+		} {
+			t.Run(name, func(t *testing.T) {
+				// act
+				lt, ltOk := compareValues(tc.a, tc.b)
+				gt, gtOk := compareValues(tc.b, tc.a)
+				eq, eqOk := compareValues(tc.a, tc.a)
+				// assert
+				assert.True(t, ltOk && gtOk && eqOk)
+				assert.Equal(t, []int{-1, 1, 0}, []int{lt, gt, eq})
+			})
+		}
+	})
+
+	t.Run("it should not order mismatched or absent values", func(t *testing.T) {
+		// act
+		_, mismatched := compareValues(msg(timestamppb.New(now)), msg(durationpb.New(time.Second)))
+		_, absent := compareValues(protoreflect.Value{}, protoreflect.ValueOfString("a"))
+		// assert
+		assert.False(t, mismatched)
+		assert.False(t, absent)
+		assert.True(t, valuesEqual(protoreflect.Value{}, protoreflect.Value{}))
+		assert.False(t, valuesEqual(protoreflect.Value{}, protoreflect.ValueOfString("")))
+	})
+}
@@ -0,0 +1,208 @@
+package propl
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// defaultValue is a default declared on a path.
+type defaultValue struct {
+	path  string
+	value protoreflect.Value
+	// unlessInMask leaves the field unset when it is in the mask
+	unlessInMask bool
+}
+
+// copy returns the value to set, copied so that messages don't share it.
+func (d *defaultValue) copy() protoreflect.Value {
+	switch v := d.value.Interface().(type) {
+	case protoreflect.Message:
+		return protoreflect.ValueOfMessage(proto.Clone(v.Interface()).ProtoReflect())
+	case []byte:
+		return protoreflect.ValueOfBytes(append([]byte(nil), v...))
+	default:
+		return d.value
+	}
+}
+
+// DefaultOption configures a Default.
+type DefaultOption func(*defaultValue)
+
+// UnlessInMask only applies the default when the path is not in the mask, so an
+// update that masks an unset field still clears it.
+func UnlessInMask() DefaultOption {
+	return func(d *defaultValue) {
+		d.unlessInMask = true
+	}
+}
+
+// Default sets the field at the provided path to value when it is not set, before
+// normalizers, the precheck and policies run. Fields without presence are unset when
+// they hold the zero value. Fields of a sub-message are only defaulted when the
+// sub-message is set. The evaluated message is modified.
+//
+// The value must be assignable to the field: a Go value of the field's kind (e.g.
+// int32 for an int32 field), a protoreflect.EnumNumber or generated enum for an enum
+// field, a message of the field's type, or a protoreflect.Value. Untyped integer
+// constants are accepted for any integer field. Otherwise, the mismatch is reported by
+// Err.
+func (r *Propl[T]) Default(path string, value any, opts ...DefaultOption) *Propl[T] {
+	desc, err := r.descriptor()
+	var fd protoreflect.FieldDescriptor
+	if err == nil {
+		fd, err = fieldAtPath(desc, path)
+	}
+	if err == nil {
+		var v protoreflect.Value
+		if v, err = defaultFieldValue(fd, value); err == nil {
+			d := &defaultValue{path: path, value: v}
+			for _, opt := range opts {
+				opt(d)
+			}
+			r.defaults = append(r.defaults, d)
+		}
+	}
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("invalid default for %s: %w", path, err))
+	}
+	return r
+}
+
+// applyDefaults sets the declared defaults on msg.
+func (r *Propl[T]) applyDefaults(msg T, maskPaths []string) {
+	if len(r.defaults) == 0 || isNilMessage(msg) {
+		return
+	}
+	store := newFieldStore(msg, maskPaths...)
+	for _, d := range r.defaults {
+		if d.unlessInMask && store.isFieldInMask(d.path) {
+			continue
+		}
+		m := msg.ProtoReflect()
+		if i := strings.LastIndex(d.path, "."); i >= 0 {
+			parent, fd, ok := resolveSetField(msg, d.path[:i])
+			if !ok || fd.Message() == nil {
+				continue
+			}
+			m = parent.Mutable(fd).Message()
+		}
+		fd := fieldByName(m.Descriptor(), d.path[strings.LastIndex(d.path, ".")+1:])
+		if m.Has(fd) {
+			continue
+		}
+		// setting a oneof member would clear the member that is set
+		if od := fd.ContainingOneof(); od != nil && m.WhichOneof(od) != nil {
+			continue
+		}
+		m.Set(fd, d.copy())
+	}
+}
+
+// fieldAtPath resolves the "." delimited path to a singular field of the message.
+func fieldAtPath(desc protoreflect.MessageDescriptor, path string) (protoreflect.FieldDescriptor, error) {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := fieldByName(desc, name)
+		if fd == nil {
+			return nil, fmt.Errorf("%s has no field %s", desc.FullName(), name)
+		}
+		if fd.IsList() || fd.IsMap() {
+			return nil, fmt.Errorf("%s is a repeated field", strings.Join(names[:i+1], "."))
+		}
+		if i == len(names)-1 {
+			return fd, nil
+		}
+		if fd.Message() == nil {
+			return nil, fmt.Errorf("%s is not a message", strings.Join(names[:i+1], "."))
+		}
+		desc = fd.Message()
+	}
+	return nil, fmt.Errorf("empty path")
+}
+
+// defaultFieldValue converts the value to a value of the field's type.
+func defaultFieldValue(fd protoreflect.FieldDescriptor, value any) (protoreflect.Value, error) {
+	if v, ok := value.(protoreflect.Value); ok {
+		value = v.Interface()
+	}
+	mismatch := fmt.Errorf("a %T can't be assigned to a %s field", value, fd.Kind())
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		m, ok := value.(proto.Message)
+		if !ok || m.ProtoReflect().Descriptor().FullName() != fd.Message().FullName() {
+			return protoreflect.Value{}, mismatch
+		}
+		return protoreflect.ValueOfMessage(proto.Clone(m).ProtoReflect()), nil
+	case protoreflect.EnumKind:
+		switch e := value.(type) {
+		case protoreflect.EnumNumber:
+			return protoreflect.ValueOfEnum(e), nil
+		case protoreflect.Enum:
+			if e.Descriptor().FullName() != fd.Enum().FullName() {
+				return protoreflect.Value{}, mismatch
+			}
+			return protoreflect.ValueOfEnum(e.Number()), nil
+		}
+		return protoreflect.Value{}, mismatch
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if i, ok := value.(int); ok {
+			return integerValue(fd.Kind(), int64(i))
+		}
+	}
+	if !sameKind(fd.Kind(), value) {
+		return protoreflect.Value{}, mismatch
+	}
+	return protoreflect.ValueOf(value), nil
+}
+
+// integerValue converts an untyped integer to a value of the integer kind.
+func integerValue(kind protoreflect.Kind, i int64) (protoreflect.Value, error) {
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		if int64(int32(i)) == i {
+			return protoreflect.ValueOfInt32(int32(i)), nil
+		}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(i), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		if i >= 0 && int64(uint32(i)) == i {
+			return protoreflect.ValueOfUint32(uint32(i)), nil
+		}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if i >= 0 {
+			return protoreflect.ValueOfUint64(uint64(i)), nil
+		}
+	}
+	return protoreflect.Value{}, fmt.Errorf("%d overflows a %s field", i, kind)
+}
+
+// sameKind reports whether the scalar value has the Go type of the field kind.
+func sameKind(kind protoreflect.Kind, value any) bool {
+	switch value.(type) {
+	case bool:
+		return kind == protoreflect.BoolKind
+	case int32:
+		return kind == protoreflect.Int32Kind || kind == protoreflect.Sint32Kind || kind == protoreflect.Sfixed32Kind
+	case int64:
+		return kind == protoreflect.Int64Kind || kind == protoreflect.Sint64Kind || kind == protoreflect.Sfixed64Kind
+	case uint32:
+		return kind == protoreflect.Uint32Kind || kind == protoreflect.Fixed32Kind
+	case uint64:
+		return kind == protoreflect.Uint64Kind || kind == protoreflect.Fixed64Kind
+	case float32:
+		return kind == protoreflect.FloatKind
+	case float64:
+		return kind == protoreflect.DoubleKind
+	case string:
+		return kind == protoreflect.StringKind
+	case []byte:
+		return kind == protoreflect.BytesKind
+	default:
+		return false
+	}
+}
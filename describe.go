@@ -0,0 +1,308 @@
+package propl
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Description is a model of the declared policies, e.g. to generate documentation
+// that can't drift from the rules a service enforces.
+type Description struct {
+	Message  protoreflect.FullName `json:"message"`
+	Policies []PolicyDescription   `json:"policies"`
+	desc     protoreflect.MessageDescriptor
+}
+
+// PolicyDescription describes a declared policy.
+type PolicyDescription struct {
+	Path  string   `json:"path"`
+	Paths []string `json:"paths,omitempty"`
+	Rule  string   `json:"rule"`
+	// Traits are the traits of a field policy, in the order of the And/Or chain.
+	Traits     []TraitDescription `json:"traits,omitempty"`
+	Conditions string             `json:"conditions,omitempty"`
+	// When are the paths of the policy's When guards.
+	When     []string `json:"when,omitempty"`
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code,omitempty"`
+	// Messages are the messages of the infractions the policy may report, in English,
+	// or the message declared with WithMessage. Custom evals report their own.
+	Messages   []string `json:"messages,omitempty"`
+	conditions Condition
+}
+
+// TraitDescription describes a trait of a field policy.
+type TraitDescription struct {
+	Type TraitType `json:"type"`
+	// Link is "and" or "or" for traits that follow another in the chain.
+	Link string `json:"link,omitempty"`
+	// Other is the field compared against, or the declared resource name patterns.
+	Other string `json:"other,omitempty"`
+}
+
+// Describe returns a model of the declared policies, in declaration order. It fails for
+// policies declared for a nil message when T is an interface.
+func (r *Propl[T]) Describe() (*Description, error) {
+	desc, err := r.descriptor()
+	if err != nil {
+		return nil, err
+	}
+	d := &Description{Message: desc.FullName(), desc: desc}
+	for _, dp := range r.policies {
+		pd := PolicyDescription{
+			Path:       dp.path,
+			Paths:      dp.paths,
+			Rule:       dp.rule,
+			Severity:   dp.options.severity,
+			Code:       dp.options.code,
+			conditions: dp.conditions,
+		}
+		if dp.conditions != 0 {
+			pd.Conditions = dp.conditions.FlagsString()
+		}
+		for _, g := range dp.options.guards {
+			pd.When = append(pd.When, g.path)
+		}
+		pd.Traits = describeTraits(dp.traits, "", nil)
+		if dp.options.message != "" {
+			pd.Messages = []string{dp.options.message}
+		} else {
+			conditions := dp.conditions
+			if len(dp.paths) > 0 {
+				// groups are described by their own rule
+				conditions = 0
+			}
+			pd.Messages = describeMessages(desc, dp.path, dp.traits, conditions, dp.infractions)
+		}
+		d.Policies = append(d.Policies, pd)
+	}
+	return d, nil
+}
+
+// describeTraits flattens the chain starting at t, which follows the previous trait by link.
+func describeTraits(t Trait, link string, traits []TraitDescription) []TraitDescription {
+	if t == nil || !t.Valid() {
+		return traits
+	}
+	td := TraitDescription{Type: t.Type(), Link: link}
+	if ct, ok := t.(*trait); ok {
+		td.Other = ct.otherPath
+		if len(ct.patterns) > 0 {
+			td.Other = strings.Join(ct.patterns, ", ")
+		}
+	}
+	traits = append(traits, td)
+	traits = describeTraits(t.And(), "and", traits)
+	return describeTraits(t.Or(), "or", traits)
+}
+
+// describeMessages renders the messages of the policy's conditions, traits and
+// other infractions. Conditions are only described for field and custom policies.
+func describeMessages(desc protoreflect.MessageDescriptor, path string, t Trait, conditions Condition, infractions []*RuleError) []string {
+	var messages []string
+	if conditions.Has(InMessage) || (t != nil && conditions.Has(InMask)) {
+		messages = append(messages, newRuleError(MessageConditions, "conditions", conditions.FlagsString()).Error())
+	}
+	var traitMessages func(t Trait)
+	traitMessages = func(t Trait) {
+		if t == nil || !t.Valid() {
+			return
+		}
+		if ct, ok := t.(*trait); ok {
+			bound := *ct
+			bound.path = path
+			if bound.traitType == ResourceNamePattern && !bound.resolved {
+				bound.resource = resourcePatterns{patterns: bound.patterns}
+				if fd := fieldByPath(desc, path); len(bound.patterns) == 0 && fd != nil {
+					bound.resource, _ = resourcePatternsOf(fd)
+				}
+			}
+			messages = append(messages, bound.infraction().Error())
+		} else {
+			messages = append(messages, t.InfractionsString())
+		}
+		traitMessages(t.And())
+		traitMessages(t.Or())
+	}
+	traitMessages(t)
+	for _, re := range infractions {
+		messages = append(messages, re.Error())
+	}
+	return messages
+}
+
+// fieldByPath resolves the field at path from the message descriptor, or returns
+// nil if the path does not resolve to a field.
+func fieldByPath(desc protoreflect.MessageDescriptor, path string) protoreflect.FieldDescriptor {
+	var fd protoreflect.FieldDescriptor
+	for _, s := range strings.Split(path, ".") {
+		if desc == nil {
+			return nil
+		}
+		fd = desc.Fields().ByName(protoreflect.Name(s))
+		if fd == nil {
+			fd = desc.Fields().ByJSONName(s)
+		}
+		if fd == nil {
+			return nil
+		}
+		desc = fd.Message()
+	}
+	return fd
+}
+
+// Markdown renders the description as a Markdown table with a row for each policy.
+func (d *Description) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n\n", d.Message)
+	b.WriteString("| Path | Rule | Conditions | When | Severity | Code | Messages |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, pd := range d.Policies {
+		rule := pd.Rule
+		if len(pd.Traits) > 0 {
+			var traits []string
+			for _, td := range pd.Traits {
+				s := td.Type.String()
+				if td.Other != "" {
+					s = fmt.Sprintf("%s(%s)", s, td.Other)
+				}
+				if td.Link != "" {
+					s = td.Link + " " + s
+				}
+				traits = append(traits, s)
+			}
+			rule = strings.Join(traits, " ")
+		}
+		var code string
+		if pd.Code != "" {
+			code = "`" + pd.Code + "`"
+		}
+		cells := []string{
+			"`" + pd.Path + "`",
+			rule,
+			pd.Conditions,
+			strings.Join(pd.When, ", "),
+			pd.Severity.String(),
+			code,
+			strings.Join(pd.Messages, "<br>"),
+		}
+		for i, c := range cells {
+			cells[i] = strings.ReplaceAll(c, "|", `\|`)
+		}
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(cells, " | "))
+	}
+	return b.String()
+}
+
+// JSONSchema returns JSON Schema annotations of the message that can be merged into
+// an OpenAPI or JSON Schema document. Properties are keyed by their JSON names.
+//
+// Only unconditional field policies with SeverityError are annotated, i.e. those
+// without When guards whose conditions include InMessage:
+//
+//   - NotZero makes a field required, with a minLength of 1 for strings and bytes
+//     and a minItems of 1 for repeated fields.
+//   - ResourceName makes a field required, and matches the pattern of its resource
+//     name patterns.
+//
+// Other policies, e.g. comparisons, custom evals and groups, can't be expressed
+// as annotations.
+func (d *Description) JSONSchema() map[string]any {
+	root := map[string]any{"type": "object"}
+	for _, pd := range d.Policies {
+		if len(pd.Traits) != 1 || len(pd.When) > 0 || pd.Severity != SeverityError || !pd.conditions.Has(InMessage) {
+			continue
+		}
+		fd := fieldByPath(d.desc, pd.Path)
+		if fd == nil {
+			continue
+		}
+		parent, property := schemaProperty(root, d.desc, pd.Path)
+		required, _ := parent["required"].([]string)
+		if !slices.Contains(required, fd.JSONName()) {
+			parent["required"] = append(required, fd.JSONName())
+		}
+		switch pd.Traits[0].Type {
+		case NotZero:
+			switch {
+			case fd.IsList() || fd.IsMap():
+				property["minItems"] = 1
+			case fd.Kind() == protoreflect.StringKind || fd.Kind() == protoreflect.BytesKind:
+				property["minLength"] = 1
+			}
+		case ResourceNamePattern:
+			patterns := strings.Split(pd.Traits[0].Other, ", ")
+			if pd.Traits[0].Other == "" {
+				rp, _ := resourcePatternsOf(fd)
+				patterns = rp.patterns
+			}
+			if len(patterns) > 0 {
+				property["pattern"] = resourceNameRegexp(patterns)
+			}
+		}
+	}
+	return root
+}
+
+// schemaProperty returns the schema of the property at path, and the schema of the
+// object it is a property of, adding any that are missing. Repeated fields are arrays,
+// and the properties of repeated messages are those of their items.
+func schemaProperty(root map[string]any, desc protoreflect.MessageDescriptor, path string) (map[string]any, map[string]any) {
+	parent, property := root, root
+	for _, s := range strings.Split(path, ".") {
+		fd := desc.Fields().ByName(protoreflect.Name(s))
+		if fd == nil {
+			fd = desc.Fields().ByJSONName(s)
+		}
+		parent = property
+		if items, ok := parent["items"].(map[string]any); ok {
+			parent = items
+		}
+		properties, ok := parent["properties"].(map[string]any)
+		if !ok {
+			properties = make(map[string]any)
+			parent["properties"] = properties
+		}
+		property, ok = properties[fd.JSONName()].(map[string]any)
+		if !ok {
+			property = make(map[string]any)
+			switch {
+			case fd.IsList():
+				property["type"] = "array"
+				if fd.Message() != nil {
+					property["items"] = map[string]any{"type": "object"}
+				}
+			case fd.Message() != nil:
+				property["type"] = "object"
+			}
+			properties[fd.JSONName()] = property
+		}
+		desc = fd.Message()
+	}
+	return parent, property
+}
+
+// resourceNameRegexp matches resource names matching any of the patterns. Each
+// variable matches one non-empty segment.
+func resourceNameRegexp(patterns []string) string {
+	alternatives := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		segments := strings.Split(p, "/")
+		for i, s := range segments {
+			if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+				segments[i] = "[^/]+"
+			} else {
+				segments[i] = regexp.QuoteMeta(s)
+			}
+		}
+		alternatives = append(alternatives, strings.Join(segments, "/"))
+	}
+	if len(alternatives) == 1 {
+		return "^" + alternatives[0] + "$"
+	}
+	return "^(" + strings.Join(alternatives, "|") + ")$"
+}
@@ -0,0 +1,239 @@
+package propl
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// testField describes a field of a message in the test library file.
+type testField struct {
+	name      string
+	kind      descriptorpb.FieldDescriptorProto_Type
+	typeName  string
+	repeated  bool
+	behaviors []fieldBehavior
+	// reference is the encoded google.api.resource_reference annotation
+	reference []byte
+	redact    bool
+}
+
+// testLibrary is a file descriptor for messages with annotations that the
+// generated test protos don't have:
+//
+//	message Author {
+//	  string display_name = 1 [(google.api.field_behavior) = REQUIRED];
+//	}
+//
+//	message Book {
+//	  string name = 1 [(google.api.field_behavior) = OUTPUT_ONLY];
+//	  string title = 2 [(google.api.field_behavior) = REQUIRED];
+//	  string isbn = 3 [(google.api.field_behavior) = REQUIRED, (google.api.field_behavior) = IMMUTABLE];
+//	  Author author = 4;
+//	  string token = 5 [debug_redact = true];
+//	}
+//
+//	message UpdateBookRequest {
+//	  Book book = 1 [(google.api.field_behavior) = REQUIRED];
+//	}
+func testLibrary(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	msg := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("propl/test/v1/library.proto"),
+		Package: proto.String("propl.test.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			testMessage("Author",
+				testField{name: "display_name", kind: str, behaviors: []fieldBehavior{behaviorRequired}},
+			),
+			testMessage("Book",
+				testField{name: "name", kind: str, behaviors: []fieldBehavior{behaviorOutputOnly}},
+				testField{name: "title", kind: str, behaviors: []fieldBehavior{behaviorRequired}},
+				testField{name: "isbn", kind: str, behaviors: []fieldBehavior{behaviorRequired, behaviorImmutable}},
+				testField{name: "author", kind: msg, typeName: ".propl.test.v1.Author"},
+				testField{name: "token", kind: str, redact: true},
+			),
+			testMessage("UpdateBookRequest",
+				testField{name: "book", kind: msg, typeName: ".propl.test.v1.Book", behaviors: []fieldBehavior{behaviorRequired}},
+			),
+		},
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fd
+}
+
+func testMessage(name string, fields ...testField) *descriptorpb.DescriptorProto {
+	dp := &descriptorpb.DescriptorProto{Name: proto.String(name)}
+	for i, f := range fields {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if f.repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		fp := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(f.name),
+			Number: proto.Int32(int32(i + 1)),
+			Label:  label.Enum(),
+			Type:   f.kind.Enum(),
+		}
+		if f.typeName != "" {
+			fp.TypeName = proto.String(f.typeName)
+		}
+		if len(f.behaviors) > 0 || f.reference != nil || f.redact {
+			fp.Options = &descriptorpb.FieldOptions{}
+			if f.redact {
+				fp.Options.DebugRedact = proto.Bool(true)
+			}
+			var unknown []byte
+			for _, b := range f.behaviors {
+				unknown = protowire.AppendTag(unknown, fieldBehaviorExtension, protowire.VarintType)
+				unknown = protowire.AppendVarint(unknown, uint64(b))
+			}
+			if f.reference != nil {
+				unknown = protowire.AppendTag(unknown, resourceReferenceExtension, protowire.BytesType)
+				unknown = protowire.AppendBytes(unknown, f.reference)
+			}
+			fp.Options.ProtoReflect().SetUnknown(unknown)
+		}
+		dp.Field = append(dp.Field, fp)
+	}
+	return dp
+}
+
+// testShelves is a file descriptor for messages with resource annotations:
+//
+//	message Shelf {
+//	  option (google.api.resource) = {type: "library.example.com/Shelf", pattern: "shelves/{shelf}"};
+//	  string name = 1;
+//	}
+//
+//	message Book {
+//	  option (google.api.resource) = {type: "library.example.com/Book", pattern: "shelves/{shelf}/books/{book}"};
+//	  string name = 1;
+//	}
+//
+//	message CreateBookRequest {
+//	  string parent = 1 [(google.api.resource_reference).child_type = "library.example.com/Book"];
+//	  Book book = 2;
+//	}
+//
+//	message GetBookRequest {
+//	  string name = 1 [(google.api.resource_reference).type = "library.example.com/Book"];
+//	  string any = 2 [(google.api.resource_reference).type = "*"];
+//	  string publisher = 3 [(google.api.resource_reference).type = "library.example.com/Publisher"];
+//	}
+func testShelves(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	msg := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("propl/test/v1/shelves.proto"),
+		Package: proto.String("propl.test.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			testResource(testMessage("Shelf", testField{name: "name", kind: str}),
+				"library.example.com/Shelf", "shelves/{shelf}"),
+			testResource(testMessage("Book", testField{name: "name", kind: str}),
+				"library.example.com/Book", "shelves/{shelf}/books/{book}"),
+			testMessage("CreateBookRequest",
+				testField{name: "parent", kind: str, reference: testReference(2, "library.example.com/Book")},
+				testField{name: "book", kind: msg, typeName: ".propl.test.v1.Book"},
+			),
+			testMessage("GetBookRequest",
+				testField{name: "name", kind: str, reference: testReference(1, "library.example.com/Book")},
+				testField{name: "any", kind: str, reference: testReference(1, "*")},
+				testField{name: "publisher", kind: str, reference: testReference(1, "library.example.com/Publisher")},
+			),
+		},
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fd
+}
+
+// testResource annotates the message with google.api.resource.
+func testResource(dp *descriptorpb.DescriptorProto, typ string, patterns ...string) *descriptorpb.DescriptorProto {
+	var rd []byte
+	rd = protowire.AppendTag(rd, 1, protowire.BytesType)
+	rd = protowire.AppendString(rd, typ)
+	for _, p := range patterns {
+		rd = protowire.AppendTag(rd, 2, protowire.BytesType)
+		rd = protowire.AppendString(rd, p)
+	}
+	var unknown []byte
+	unknown = protowire.AppendTag(unknown, resourceExtension, protowire.BytesType)
+	unknown = protowire.AppendBytes(unknown, rd)
+	dp.Options = &descriptorpb.MessageOptions{}
+	dp.Options.ProtoReflect().SetUnknown(unknown)
+	return dp
+}
+
+// testReference encodes a google.api.resource_reference with the type (1) or child_type (2).
+func testReference(num protowire.Number, typ string) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, typ)
+}
+
+// newTestMessage creates an empty dynamic message of the named type in fd.
+func newTestMessage(fd protoreflect.FileDescriptor, name protoreflect.Name) *dynamicpb.Message {
+	return dynamicpb.NewMessage(fd.Messages().ByName(name))
+}
+
+// setTestField sets the field at the "." delimited path, creating parent messages as needed.
+func setTestField(m protoreflect.Message, path []protoreflect.Name, v protoreflect.Value) {
+	fd := m.Descriptor().Fields().ByName(path[0])
+	if len(path) == 1 {
+		m.Set(fd, v)
+		return
+	}
+	setTestField(m.Mutable(fd).Message(), path[1:], v)
+}
+
+// testTree is a file descriptor for a recursive message:
+//
+//	message Node {
+//	  string name = 1;
+//	  Node parent = 2;
+//	  repeated Node children = 3;
+//	  map<string, Node> links = 4;
+//	}
+func testTree(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	msg := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	node := testMessage("Node",
+		testField{name: "name", kind: str},
+		testField{name: "parent", kind: msg, typeName: ".propl.test.v1.Node"},
+		testField{name: "children", kind: msg, typeName: ".propl.test.v1.Node", repeated: true},
+		testField{name: "links", kind: msg, typeName: ".propl.test.v1.Node.LinksEntry", repeated: true},
+	)
+	entry := testMessage("LinksEntry",
+		testField{name: "key", kind: str},
+		testField{name: "value", kind: msg, typeName: ".propl.test.v1.Node"},
+	)
+	entry.Options = &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)}
+	node.NestedType = append(node.NestedType, entry)
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String("propl/test/v1/tree.proto"),
+		Package:     proto.String("propl.test.v1"),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{node},
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fd
+}
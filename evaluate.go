@@ -0,0 +1,188 @@
+package propl
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type EvaluationMode uint32
+
+const (
+	// CollectAll evaluates every policy.
+	CollectAll EvaluationMode = iota
+	// FailFast stops evaluating at the first infraction. Infractions below
+	// SeverityError never stop evaluation in any mode.
+	FailFast
+	// MaxInfractions stops evaluating once a maximum number of infractions
+	// have been found. Set the maximum using WithMaxInfractions; Err reports the
+	// mode without one.
+	MaxInfractions
+	// StopPerPath skips the remaining policies on a path once one of its policies
+	// fails, e.g. so a pattern isn't checked against a value that is empty.
+	StopPerPath
+)
+
+// infractionLimit is the number of infractions after which evaluation stops, or
+// 0 if there is no limit.
+func (r *Propl[T]) infractionLimit() int {
+	switch r.mode {
+	case FailFast:
+		return 1
+	case MaxInfractions:
+		return r.maxInfractions
+	default:
+		return 0
+	}
+}
+
+// executeSerially records each policy's error at the policy's index in errs.
+func (r *Propl[T]) executeSerially(ctx context.Context, bound []*boundPolicy, errs []error) {
+	var (
+		found  int
+		limit  = r.infractionLimit()
+		failed = make(map[string]bool)
+	)
+	for i, bp := range bound {
+		if ctx.Err() != nil || (limit > 0 && found >= limit) {
+			return
+		}
+		if r.mode == StopPerPath && failed[bp.path] {
+			continue
+		}
+		if errs[i] = r.execute(ctx, bp); failsEvaluation(bp, errs[i]) {
+			found++
+			failed[bp.path] = true
+		}
+	}
+}
+
+// executeConcurrently runs at most r.concurrency units at a time, recording each
+// policy's error at the policy's index in errs so the result does not depend on the
+// order policies complete in.
+//
+// Once the infraction limit is reached no more units are started, but units that
+// are already running are allowed to finish.
+func (r *Propl[T]) executeConcurrently(ctx context.Context, bound []*boundPolicy, errs []error) {
+	var (
+		wg    sync.WaitGroup
+		found atomic.Int64
+		limit = int64(r.infractionLimit())
+		sem   = make(chan struct{}, r.concurrency)
+	)
+	done := func() bool {
+		return limit > 0 && found.Load() >= limit
+	}
+	defer wg.Wait()
+	for _, unit := range r.executionUnits(bound) {
+		select {
+		case <-ctx.Done():
+			return
+		case sem <- struct{}{}:
+		}
+		if done() {
+			<-sem
+			return
+		}
+		wg.Add(1)
+		go func(unit []int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			for n, i := range unit {
+				// a unit that has been started evaluates at least one policy so the
+				// infractions reported don't depend on scheduling
+				if ctx.Err() != nil || (n > 0 && done()) {
+					return
+				}
+				if errs[i] = r.execute(ctx, bound[i]); failsEvaluation(bound[i], errs[i]) {
+					found.Add(1)
+					// units only hold more than one policy when stopping per path
+					return
+				}
+			}
+		}(unit)
+	}
+}
+
+// executionUnits groups the indexes of policies that must be evaluated in sequence.
+// When stopping per path, each path's policies make up a unit. Otherwise each
+// policy is its own unit.
+func (r *Propl[T]) executionUnits(bound []*boundPolicy) [][]int {
+	var units [][]int
+	if r.mode != StopPerPath {
+		for i := range bound {
+			units = append(units, []int{i})
+		}
+		return units
+	}
+	byPath := make(map[string]int)
+	for i, bp := range bound {
+		u, ok := byPath[bp.path]
+		if !ok {
+			u = len(units)
+			byPath[bp.path] = u
+			units = append(units, nil)
+		}
+		units[u] = append(units[u], i)
+	}
+	return units
+}
+
+// execute runs the policy, calling the hooks before and after.
+func (r *Propl[T]) execute(ctx context.Context, bp *boundPolicy) error {
+	if len(r.hooks) == 0 || isDryRun(ctx) {
+		return r.run(ctx, bp)
+	}
+	for _, h := range r.hooks {
+		h.OnPolicyStart(ctx, bp.info)
+	}
+	start := time.Now()
+	action := bp.action()
+	err := r.run(ctx, bp)
+	result := PolicyResult{Action: action, Err: err, Duration: time.Since(start)}
+	for _, h := range r.hooks {
+		h.OnPolicyResult(ctx, bp.info, result)
+	}
+	return err
+}
+
+// run runs the policy, enforcing the policy timeout if one is set. Policies
+// whose When guards do not hold are skipped.
+//
+// The policy runs on the calling goroutine, so it never outlives the evaluation
+// and can't touch the result or an Explain trace once they are read. A policy that
+// doesn't honor the context therefore can't be interrupted, and is only reported as
+// incomplete once it returns.
+func (r *Propl[T]) run(ctx context.Context, bp *boundPolicy) error {
+	if !bp.guardsHold() {
+		return nil
+	}
+	p := bp.policy
+	if r.policyTimeout <= 0 {
+		return p.Execute(ctx)
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, r.policyTimeout)
+	defer cancel()
+	err := p.Execute(timeoutCtx)
+	if timeoutCtx.Err() != nil && ctx.Err() == nil {
+		return wrapRuleError(MessageIncomplete, timeoutCtx.Err())
+	}
+	return err
+}
+
+// failsEvaluation reports whether err from the policy counts towards the
+// infraction limit, i.e. whether it fails evaluation.
+func failsEvaluation(bp *boundPolicy, err error) bool {
+	if err == nil {
+		return false
+	}
+	var nested *nestedInfractions
+	if errors.As(err, &nested) {
+		return nested.result.Failed()
+	}
+	return bp.options.severity == SeverityError
+}
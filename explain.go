@@ -0,0 +1,311 @@
+package propl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Outcome is how a policy fared in an evaluation.
+type Outcome string
+
+const (
+	// OutcomePassed policies were checked and reported no infraction.
+	OutcomePassed Outcome = "passed"
+	// OutcomeFailed policies reported an infraction, of any severity.
+	OutcomeFailed Outcome = "failed"
+	// OutcomeSkipped policies were not checked because of their conditions or
+	// their When guards.
+	OutcomeSkipped Outcome = "skipped"
+	// OutcomeNotEvaluated policies were not evaluated because evaluation stopped
+	// before reaching them, e.g. in FailFast mode, or because they load the stored
+	// resource, which Explain doesn't do.
+	OutcomeNotEvaluated Outcome = "not evaluated"
+)
+
+// Explanation is a trace of an evaluation, with a PolicyTrace for each declared
+// policy in declaration order. It prints as text, and marshals to JSON.
+type Explanation struct {
+	Message  protoreflect.FullName `json:"message"`
+	Mask     []string              `json:"mask,omitempty"`
+	Policies []*PolicyTrace        `json:"policies"`
+	// Result is the result of the evaluation
+	Result *Result `json:"-"`
+}
+
+// PolicyTrace describes how a policy was evaluated.
+type PolicyTrace struct {
+	Path  string   `json:"path"`
+	Paths []string `json:"paths,omitempty"`
+	Rule  string   `json:"rule"`
+	// Fields are the fields the policy is declared on, and whether they were found
+	// in the message and the mask.
+	Fields     []FieldTrace `json:"fields"`
+	Conditions string       `json:"conditions,omitempty"`
+	Severity   Severity     `json:"severity"`
+	// Action is how the policy's conditions and When guards said to evaluate it.
+	Action Action `json:"action"`
+	// Traits are the traits checked, in the order they were checked in the And/Or chain.
+	Traits  []TraitTrace `json:"traits,omitempty"`
+	Outcome Outcome      `json:"outcome"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// FieldTrace describes a field a policy is declared on.
+type FieldTrace struct {
+	Path      string `json:"path"`
+	InMessage bool   `json:"in_message"`
+	InMask    bool   `json:"in_mask"`
+}
+
+// TraitTrace describes a trait that was checked.
+type TraitTrace struct {
+	Type TraitType `json:"type"`
+	// Link is "and" or "or" for traits that follow another in the chain.
+	Link string `json:"link,omitempty"`
+	// Other is the field compared against, or the resource name patterns.
+	Other string `json:"other,omitempty"`
+	Held  bool   `json:"held"`
+}
+
+// Explain evaluates the declared policies like Check, and returns a trace of how each
+// policy was evaluated along with the result. Use it to tell why a request was
+// rejected or accepted, e.g. whether a policy was skipped because its field was not
+// in the mask or checked and passed.
+//
+// Explain is a dry run: it evaluates a copy of the message, so defaults and
+// normalizers don't modify it, and it doesn't call the hooks or the warnings handler.
+// Policies that load the stored resource, such as Immutable and ValidateMerged, are
+// not evaluated.
+func (r *Propl[T]) Explain(ctx context.Context) (*Explanation, error) {
+	if err := r.errNoMessage(); err != nil {
+		return nil, err
+	}
+	return r.explain(ctx, r.msg, r.maskPaths)
+}
+
+// ExplainMessage is Explain for msg and maskPaths, the counterpart of CheckMessage.
+// Use it to explain the policies of a set declared with ForDescriptor.
+func (r *Propl[T]) ExplainMessage(ctx context.Context, msg proto.Message, maskPaths ...string) (*Explanation, error) {
+	t, err := r.assertMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+	return r.explain(ctx, t, maskPaths)
+}
+
+// ExplainReflect is ExplainMessage for a protoreflect.Message, e.g. a dynamicpb message.
+func (r *Propl[T]) ExplainReflect(ctx context.Context, msg protoreflect.Message, maskPaths ...string) (*Explanation, error) {
+	return r.ExplainMessage(ctx, msg.Interface(), maskPaths...)
+}
+
+// explain dry runs the policies against a copy of msg.
+func (r *Propl[T]) explain(ctx context.Context, msg T, maskPaths []string) (*Explanation, error) {
+	msg = proto.Clone(msg).(T)
+	explanation := &Explanation{
+		Message: msg.ProtoReflect().Descriptor().FullName(),
+		Mask:    maskPaths,
+	}
+	res, err := r.check(context.WithValue(ctx, dryRunKey{}, true), msg, maskPaths, false, explanation)
+	if err != nil {
+		return nil, err
+	}
+	explanation.Result = res
+	return explanation, nil
+}
+
+type dryRunKey struct{}
+
+// isDryRun reports whether ctx is the context of an Explain, which has no side
+// effects.
+func isDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+// loadingPolicy is implemented by policies that load the stored resource. They pass
+// without loading it in a dry run.
+type loadingPolicy interface {
+	loadsResource()
+}
+
+// tracedPolicy records whether the policy was evaluated.
+type tracedPolicy struct {
+	Policy
+	evaluated *bool
+}
+
+func (tp *tracedPolicy) Execute(ctx context.Context) error {
+	if _, ok := tp.Policy.(loadingPolicy); ok {
+		return nil
+	}
+	*tp.evaluated = true
+	return tp.Policy.Execute(ctx)
+}
+
+func (tp *tracedPolicy) action() Action {
+	if cp, ok := tp.Policy.(conditionalPolicy); ok {
+		return cp.action()
+	}
+	return Check
+}
+
+// policyTracer collects the trace of a bound policy.
+type policyTracer struct {
+	trace     *PolicyTrace
+	evaluated bool
+}
+
+// tracePolicies starts a trace for each bound policy, wrapping the policies so
+// the traits they check and whether they are evaluated are recorded.
+func tracePolicies[T proto.Message](r *Propl[T], bound []*boundPolicy, store *fieldStore[T]) []*policyTracer {
+	tracers := make([]*policyTracer, len(bound))
+	for i, bp := range bound {
+		pt := &policyTracer{
+			trace: &PolicyTrace{
+				Path:     bp.path,
+				Paths:    bp.paths,
+				Rule:     r.policies[i].rule,
+				Severity: bp.options.severity,
+				Action:   bp.action(),
+			},
+		}
+		paths := bp.paths
+		if len(paths) == 0 {
+			paths = []string{bp.path}
+		}
+		for _, p := range paths {
+			ft := FieldTrace{Path: p, InMask: store.isFieldInMask(p)}
+			if f := store.loadFieldsFromPath(p).getByPath(p); f != nil {
+				ft.InMessage = f.s()
+			}
+			pt.trace.Fields = append(pt.trace.Fields, ft)
+		}
+		if p, ok := bp.policy.(*policy); ok {
+			pt.trace.Conditions = p.conditions.FlagsString()
+			p.trace = &pt.trace.Traits
+		}
+		bp.policy = &tracedPolicy{Policy: bp.policy, evaluated: &pt.evaluated}
+		tracers[i] = pt
+	}
+	return tracers
+}
+
+// finishTraces records the outcome of each policy from its error.
+func finishTraces(explanation *Explanation, tracers []*policyTracer, bound []*boundPolicy, errs []error) {
+	for i, pt := range tracers {
+		trace := pt.trace
+		switch {
+		case !pt.evaluated && bound[i].guardsHold():
+			trace.Outcome = OutcomeNotEvaluated
+		case trace.Action == Skip:
+			trace.Outcome = OutcomeSkipped
+		case errs[i] == nil:
+			trace.Outcome = OutcomePassed
+		default:
+			trace.Outcome = OutcomeFailed
+			trace.Error = errs[i].Error()
+		}
+		explanation.Policies = append(explanation.Policies, trace)
+	}
+}
+
+// String prints the trace with a line for each policy, followed by its fields and
+// the traits it checked.
+func (e *Explanation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s", e.Message)
+	if len(e.Mask) > 0 {
+		fmt.Fprintf(&b, " (mask: %s)", strings.Join(e.Mask, ", "))
+	}
+	b.WriteString("\n")
+	for _, pt := range e.Policies {
+		fmt.Fprintf(&b, "%s %s: %s", pt.Rule, pt.Path, pt.Outcome)
+		if pt.Error != "" {
+			fmt.Fprintf(&b, ": %s", pt.Error)
+		}
+		fmt.Fprintf(&b, " [action: %s, severity: %s", pt.Action, pt.Severity)
+		if pt.Conditions != "" {
+			fmt.Fprintf(&b, ", conditions: %s", pt.Conditions)
+		}
+		b.WriteString("]\n")
+		for _, ft := range pt.Fields {
+			fmt.Fprintf(&b, "  field %s: in message: %t, in mask: %t\n", ft.Path, ft.InMessage, ft.InMask)
+		}
+		for _, tt := range pt.Traits {
+			b.WriteString("  ")
+			if tt.Link != "" {
+				fmt.Fprintf(&b, "%s ", tt.Link)
+			}
+			b.WriteString(tt.Type.String())
+			if tt.Other != "" {
+				fmt.Fprintf(&b, "(%s)", tt.Other)
+			}
+			if tt.Held {
+				b.WriteString(": held\n")
+			} else {
+				b.WriteString(": not held\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// traceTrait describes the trait and whether the subject held it.
+func traceTrait(t Trait, link string, held bool) TraitTrace {
+	tt := TraitTrace{Type: t.Type(), Link: link, Held: held}
+	if ct, ok := t.(*trait); ok {
+		tt.Other = ct.otherPath
+		if len(ct.resource.patterns) > 0 {
+			tt.Other = strings.Join(ct.resource.patterns, ", ")
+		}
+	}
+	return tt
+}
+
+// MarshalText encodes the action as its name.
+func (a Action) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText decodes an action from its name.
+func (a *Action) UnmarshalText(text []byte) error {
+	return unmarshalName(text, a, Action(len(_Action_index)-1))
+}
+
+// MarshalText encodes the trait type as its name.
+func (t TraitType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText decodes a trait type from its name.
+func (t *TraitType) UnmarshalText(text []byte) error {
+	return unmarshalName(text, t, TraitType(len(_TraitType_index)-1))
+}
+
+// MarshalText encodes the severity as its name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity from its name.
+func (s *Severity) UnmarshalText(text []byte) error {
+	return unmarshalName(text, s, SeverityInfo+1)
+}
+
+// unmarshalName sets v to the value below end whose name is text.
+func unmarshalName[E interface {
+	~uint32
+	String() string
+}](text []byte, v *E, end E) error {
+	for e := E(0); e < end; e++ {
+		if e.String() == string(text) {
+			*v = e
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown %T %q", *v, text)
+}
@@ -2,6 +2,7 @@ package propl
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -9,12 +10,100 @@ import (
 
 type FieldInfractionsHandler func(errs map[string]error) error
 
+// OrderedFieldInfractionsHandler receives infractions in the order their policies
+// were declared.
+type OrderedFieldInfractionsHandler func(infractions FieldInfractions) error
+
+// WarningsHandler receives infractions that do not fail evaluation.
+type WarningsHandler func(ctx context.Context, warnings FieldInfractions)
+
+// FieldInfraction is the error produced by the policy on the field at Path.
+// For message-level policies, Path identifies the group and Paths lists
+// each field involved.
+type FieldInfraction struct {
+	Path     string
+	Paths    []string
+	Err      error
+	Severity Severity
+	// Value is the field's value when it is a set scalar or enum, or Redacted
+	// if the field is sensitive.
+	Value string
+	// Code is the code declared for the policy with WithCode, if any.
+	Code string
+}
+
+// FieldInfractions are infractions in policy declaration order. A path with
+// more than one policy may appear more than once.
+type FieldInfractions []FieldInfraction
+
+// Paths returns each path with an infraction once, in order of first appearance.
+func (fi FieldInfractions) Paths() []string {
+	var paths []string
+	seen := make(map[string]struct{})
+	for _, i := range fi {
+		if _, ok := seen[i.Path]; !ok {
+			seen[i.Path] = struct{}{}
+			paths = append(paths, i.Path)
+		}
+	}
+	return paths
+}
+
+// ByPath aggregates the infractions on each path into a list.
+func (fi FieldInfractions) ByPath() map[string][]error {
+	errs := make(map[string][]error)
+	for _, i := range fi {
+		errs[i.Path] = append(errs[i.Path], i.Err)
+	}
+	return errs
+}
+
+// Map indexes the infractions by path. Paths with more than one infraction
+// map to the joined errors.
+func (fi FieldInfractions) Map() map[string]error {
+	errs := make(map[string]error)
+	for p, pe := range fi.ByPath() {
+		errs[p] = errors.Join(pe...)
+	}
+	return errs
+}
+
+// Violation mirrors google.rpc.BadRequest.FieldViolation, so infractions can be
+// returned as gRPC error details without propl depending on gRPC.
+type Violation struct {
+	Field       string
+	Description string
+	// Reason is the infraction's Code
+	Reason string
+}
+
+// Violations describes each infraction as a field violation. Message-level
+// infractions have a violation for each field involved.
+func (fi FieldInfractions) Violations() []Violation {
+	violations := make([]Violation, 0, len(fi))
+	for _, i := range fi {
+		fields := i.Paths
+		if len(fields) == 0 {
+			fields = []string{i.Path}
+		}
+		for _, f := range fields {
+			violations = append(violations, Violation{Field: f, Description: i.Err.Error(), Reason: i.Code})
+		}
+	}
+	return violations
+}
+
 // defaultFieldInfractionsHandler if no FieldInfractionsHandler specified
-func defaultFieldInfractionsHandler(errs map[string]error) error {
+func defaultFieldInfractionsHandler(infractions FieldInfractions) error {
 	var buffer bytes.Buffer
+	byPath := infractions.ByPath()
 	buffer.WriteString("field infractions: [\n")
-	for k, v := range errs {
-		buffer.WriteString(fmt.Sprintf("%s: %s\\n\n", k, v.Error()))
+	for _, p := range infractions.Paths() {
+		msgs := make([]string, 0, len(byPath[p]))
+		for _, err := range byPath[p] {
+			msgs = append(msgs, err.Error())
+		}
+		buffer.WriteString(fmt.Sprintf("%s: %s\\n\n", p, strings.Join(msgs, "; ")))
 	}
 	buffer.WriteString("]\n")
 	return errors.New(buffer.String())
@@ -0,0 +1,209 @@
+package propl
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Operation is the kind of request a message is validated for. It determines
+// how field behavior annotations translate to policies.
+type Operation uint32
+
+const (
+	Create Operation = iota
+	Update
+)
+
+// fieldBehavior mirrors the google.api.FieldBehavior enum.
+type fieldBehavior int32
+
+const (
+	behaviorRequired   fieldBehavior = 2
+	behaviorOutputOnly fieldBehavior = 3
+	behaviorImmutable  fieldBehavior = 5
+)
+
+// rule names the policy enforcing the behavior.
+func (b fieldBehavior) rule() string {
+	switch b {
+	case behaviorRequired:
+		return "Required"
+	case behaviorOutputOnly:
+		return "OutputOnly"
+	case behaviorImmutable:
+		return "ImmutableBehavior"
+	default:
+		return fmt.Sprintf("FieldBehavior(%d)", b)
+	}
+}
+
+// infractions are the errors reported by the policy enforcing the behavior.
+func (b fieldBehavior) infractions() []*RuleError {
+	switch b {
+	case behaviorOutputOnly:
+		return []*RuleError{newRuleError(MessageOutputOnlyInMask), newRuleError(MessageOutputOnlySet)}
+	case behaviorImmutable:
+		return []*RuleError{newRuleError(MessageImmutableInMask)}
+	default:
+		return nil
+	}
+}
+
+// fieldBehaviorExtension is the field number of the google.api.field_behavior
+// extension of google.protobuf.FieldOptions.
+const fieldBehaviorExtension protowire.Number = 1052
+
+// FieldBehaviorPolicies declares policies derived from the google.api.field_behavior
+// annotations on the message's fields, and the fields of any singular sub-messages:
+//
+//   - REQUIRED fields are NeverZero on Create and NeverZeroWhen(InMask) on Update.
+//     Required fields of a sub-message only apply when the sub-message is set.
+//   - OUTPUT_ONLY fields must not be set or appear in the mask.
+//   - IMMUTABLE fields must not appear in the mask on Update.
+//
+// Other behaviors, such as INPUT_ONLY, don't constrain requests.
+//
+// The annotations are read from the descriptor's options, so the generated code for
+// google/api/field_behavior.proto does not need to be linked in.
+func (r *Propl[T]) FieldBehaviorPolicies(op Operation, opts ...PolicyOption) *Propl[T] {
+	desc, err := r.descriptor()
+	if err != nil {
+		r.errs = append(r.errs, err)
+		return r
+	}
+	r.fieldBehaviorPolicies(op, desc, "", nil, map[protoreflect.FullName]bool{}, opts)
+	return r
+}
+
+func (r *Propl[T]) fieldBehaviorPolicies(op Operation, desc protoreflect.MessageDescriptor, prefix string, parents []string, visiting map[protoreflect.FullName]bool, opts []PolicyOption) {
+	if visiting[desc.FullName()] {
+		return
+	}
+	visiting[desc.FullName()] = true
+	defer delete(visiting, desc.FullName())
+	// fields of a sub-message only apply when the sub-message is set
+	var guarded []PolicyOption
+	for _, p := range parents {
+		guarded = append(guarded, When(p, IsSet()))
+	}
+	guarded = append(guarded, opts...)
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		path := getPath(prefix, string(fd.Name()))
+		for _, b := range fieldBehaviors(fd) {
+			b := b
+			switch {
+			case b == behaviorRequired && op == Create:
+				r.NeverZero(path, guarded...)
+			case b == behaviorRequired && op == Update:
+				r.NeverZeroWhen(path, InMask, guarded...)
+			case b == behaviorOutputOnly, b == behaviorImmutable && op == Update:
+				r.addPolicy(path, b.rule(), fmt.Sprintf("behavior %d", b), func(store *fieldStore[T]) Policy {
+					return &behaviorPolicy{
+						subject:  store.loadFieldsFromPath(path).getByPath(path),
+						behavior: b,
+					}
+				}, guarded).infractions = b.infractions()
+			}
+		}
+		if fd.Message() != nil && !fd.IsList() && !fd.IsMap() && !isWellKnown(fd.Message()) {
+			r.fieldBehaviorPolicies(op, fd.Message(), path, append(parents[:len(parents):len(parents)], path), visiting, opts)
+		}
+	}
+}
+
+// fieldBehaviors reads the google.api.field_behavior annotation from the field's
+// options, whether or not the extension is registered.
+func fieldBehaviors(fd protoreflect.FieldDescriptor) []fieldBehavior {
+	opts := fd.Options()
+	if opts == nil {
+		return nil
+	}
+	b, err := proto.Marshal(opts)
+	if err != nil {
+		return nil
+	}
+	var behaviors []fieldBehavior
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return behaviors
+		}
+		b = b[n:]
+		switch {
+		case num == fieldBehaviorExtension && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return behaviors
+			}
+			behaviors = append(behaviors, fieldBehavior(v))
+			b = b[n:]
+		case num == fieldBehaviorExtension && typ == protowire.BytesType:
+			// packed
+			packed, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return behaviors
+			}
+			for len(packed) > 0 {
+				v, m := protowire.ConsumeVarint(packed)
+				if m < 0 {
+					break
+				}
+				behaviors = append(behaviors, fieldBehavior(v))
+				packed = packed[m:]
+			}
+			b = b[n:]
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return behaviors
+			}
+			b = b[n:]
+		}
+	}
+	return behaviors
+}
+
+// isWellKnown reports whether the message is one of the google.protobuf types,
+// which carry no field behavior annotations.
+func isWellKnown(md protoreflect.MessageDescriptor) bool {
+	return md.ParentFile() != nil && md.ParentFile().Package() == "google.protobuf"
+}
+
+var _ Policy = (*behaviorPolicy)(nil)
+
+// behaviorPolicy enforces the OUTPUT_ONLY and IMMUTABLE field behaviors. Its
+// conditions are fixed by the behavior.
+type behaviorPolicy struct {
+	subject  *fieldData
+	behavior fieldBehavior
+}
+
+func (b *behaviorPolicy) Execute(ctx context.Context) error {
+	return b.EvaluateSubjectTraits(ctx)
+}
+
+func (b *behaviorPolicy) EvaluateSubjectTraits(_ context.Context) error {
+	if b.subject == nil {
+		return nil
+	}
+	switch b.behavior {
+	case behaviorOutputOnly:
+		if b.subject.m() {
+			return newRuleError(MessageOutputOnlyInMask)
+		}
+		if b.subject.s() && !b.subject.z() {
+			return newRuleError(MessageOutputOnlySet)
+		}
+	case behaviorImmutable:
+		if b.subject.m() {
+			return newRuleError(MessageImmutableInMask)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package propl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestFieldBehaviorPolicies(t *testing.T) {
+	library := testLibrary(t)
+
+	t.Run("it should require fields on create", func(t *testing.T) {
+		// arrange
+		req := newTestMessage(library, "UpdateBookRequest")
+		setTestField(req, []protoreflect.Name{"book", "title"}, protoreflect.ValueOfString("dune"))
+		setTestField(req, []protoreflect.Name{"book", "name"}, protoreflect.ValueOfString("books/1"))
+		p := For(req).FieldBehaviorPolicies(Create)
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"book.name", "book.isbn"}, res.Infractions.Paths())
+	})
+
+	t.Run("it should only require sub-message fields when the sub-message is set", func(t *testing.T) {
+		// arrange
+		req := newTestMessage(library, "UpdateBookRequest")
+		setTestField(req, []protoreflect.Name{"book", "title"}, protoreflect.ValueOfString("dune"))
+		setTestField(req, []protoreflect.Name{"book", "isbn"}, protoreflect.ValueOfString("0441013597"))
+		withAuthor := newTestMessage(library, "UpdateBookRequest")
+		setTestField(withAuthor, []protoreflect.Name{"book", "title"}, protoreflect.ValueOfString("dune"))
+		setTestField(withAuthor, []protoreflect.Name{"book", "isbn"}, protoreflect.ValueOfString("0441013597"))
+		setTestField(withAuthor, []protoreflect.Name{"book", "author", "display_name"}, protoreflect.ValueOfString(""))
+		// act
+		res, err := For(req).FieldBehaviorPolicies(Create).Check(context.Background())
+		withAuthorRes, withAuthorErr := For(withAuthor).FieldBehaviorPolicies(Create).Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.NoError(t, withAuthorErr)
+		assert.Empty(t, res.Infractions)
+		assert.Equal(t, []string{"book.author.display_name"}, withAuthorRes.Infractions.Paths())
+	})
+
+	t.Run("it should check the mask on update", func(t *testing.T) {
+		// arrange
+		req := newTestMessage(library, "UpdateBookRequest")
+		setTestField(req, []protoreflect.Name{"book", "isbn"}, protoreflect.ValueOfString("0441013597"))
+		p := For(req, "name", "title", "isbn").FieldBehaviorPolicies(Update)
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"book.name", "book.title", "book.isbn"}, res.Infractions.Paths())
+		assert.EqualError(t, res.Infractions[0].Err, "it is output only and should not be in the mask")
+		assert.EqualError(t, res.Infractions[2].Err, "it is immutable and should not be in the mask")
+	})
+}
@@ -0,0 +1,103 @@
+package propl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type groupKind uint32
+
+const (
+	atLeastOneOf groupKind = iota
+	mutuallyExclusive
+	allOrNone
+	exactlyOneOf
+)
+
+func (k groupKind) String() string {
+	switch k {
+	case atLeastOneOf:
+		return "AtLeastOneOf"
+	case mutuallyExclusive:
+		return "MutuallyExclusive"
+	case allOrNone:
+		return "AllOrNone"
+	default:
+		return "ExactlyOneOf"
+	}
+}
+
+// message is the ID of the message of the group's infractions.
+func (k groupKind) message() string {
+	switch k {
+	case atLeastOneOf:
+		return MessageAtLeastOneOf
+	case mutuallyExclusive:
+		return MessageMutuallyExclusive
+	case allOrNone:
+		return MessageAllOrNone
+	default:
+		return MessageExactlyOneOf
+	}
+}
+
+// groupKey identifies a message-level policy in place of a path.
+func groupKey(kind groupKind, paths []string) string {
+	return fmt.Sprintf("%s(%s)", kind, strings.Join(paths, ", "))
+}
+
+var _ Policy = (*groupPolicy)(nil)
+
+// groupPolicy is a message-level policy on how many of a group of fields are present.
+// A field is present when it is set to a non-zero value.
+//
+// If the conditions only contain InMask, the policy only considers the fields in
+// the mask and is skipped when none of them are. Otherwise every field in the group
+// is considered.
+type groupPolicy struct {
+	kind       groupKind
+	paths      []string
+	subjects   []*fieldData
+	conditions Condition
+}
+
+func (g *groupPolicy) Execute(ctx context.Context) error {
+	return g.EvaluateSubjectTraits(ctx)
+}
+
+func (g *groupPolicy) EvaluateSubjectTraits(_ context.Context) error {
+	maskOnly := g.conditions.Has(InMask) && !g.conditions.Has(InMessage)
+	var considered, present []string
+	for i, s := range g.subjects {
+		if maskOnly && (s == nil || !s.m()) {
+			continue
+		}
+		considered = append(considered, g.paths[i])
+		if s != nil && s.s() && !s.z() {
+			present = append(present, g.paths[i])
+		}
+	}
+	if len(considered) == 0 {
+		return nil
+	}
+	switch g.kind {
+	case atLeastOneOf:
+		if len(present) == 0 {
+			return newRuleError(g.kind.message(), "paths", strings.Join(considered, ", "))
+		}
+	case mutuallyExclusive:
+		if len(present) > 1 {
+			return newRuleError(g.kind.message(), "paths", strings.Join(present, ", "))
+		}
+	case allOrNone:
+		if len(present) > 0 && len(present) < len(considered) {
+			return newRuleError(g.kind.message(), "paths", strings.Join(considered, ", "))
+		}
+	case exactlyOneOf:
+		if len(present) != 1 {
+			return newRuleError(g.kind.message(), "paths", strings.Join(considered, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package propl
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Hooks observe an evaluation, e.g. to record metrics. Hooks of policies evaluated
+// concurrently are called concurrently. Embed NopHooks to implement only some of
+// the methods.
+type Hooks interface {
+	// OnEvaluateStart is called before any policy is evaluated. The context it
+	// returns is passed to the policies and the other hooks, e.g. to start a span
+	// that OnEvaluateDone ends.
+	OnEvaluateStart(ctx context.Context, evaluation EvaluationInfo) context.Context
+	// OnPolicyStart is called before a policy is evaluated.
+	OnPolicyStart(ctx context.Context, policy PolicyInfo)
+	// OnPolicyResult is called after a policy is evaluated.
+	OnPolicyResult(ctx context.Context, policy PolicyInfo, result PolicyResult)
+	// OnEvaluateDone is called once evaluation completes with its result, or the
+	// error that stopped it.
+	OnEvaluateDone(ctx context.Context, evaluation EvaluationInfo, res *Result, err error)
+}
+
+// PolicyInfo describes a declared policy.
+type PolicyInfo struct {
+	// Message is the full name of the message the policy is evaluated against.
+	Message protoreflect.FullName
+	// Path is the policy's path, or the group key of a message-level policy.
+	Path string
+	// Paths are the fields of a message-level policy.
+	Paths []string
+	// Rule names the kind of policy, e.g. "NotZero", "CustomEval" or "AtLeastOneOf".
+	Rule     string
+	Code     string
+	Severity Severity
+}
+
+// PolicyResult is the outcome of evaluating a policy.
+type PolicyResult struct {
+	// Action is Skip when the policy was not evaluated, either because of its
+	// conditions or its When guards, Fail when the subject did not meet the
+	// policy's conditions, and Check otherwise.
+	Action Action
+	// Err is the policy's infraction, if any.
+	Err      error
+	Duration time.Duration
+}
+
+// EvaluationInfo describes an evaluation.
+type EvaluationInfo struct {
+	Message  protoreflect.FullName
+	Policies int
+	Mode     EvaluationMode
+	// Duration is the time evaluation took. It is zero in OnEvaluateStart.
+	Duration time.Duration
+}
+
+// WithHooks adds hooks that observe each evaluation.
+func (r *Propl[T]) WithHooks(hooks ...Hooks) *Propl[T] {
+	r.hooks = append(r.hooks, hooks...)
+	return r
+}
+
+// evaluateStart calls the hooks before evaluating msg, returning the context the
+// evaluation continues with.
+func (r *Propl[T]) evaluateStart(ctx context.Context, msg T) context.Context {
+	info := r.evaluationInfo(msg)
+	for _, h := range r.hooks {
+		ctx = h.OnEvaluateStart(ctx, info)
+	}
+	return ctx
+}
+
+// evaluateDone calls the hooks with the outcome of evaluating msg.
+func (r *Propl[T]) evaluateDone(ctx context.Context, msg T, start time.Time, res *Result, err error) {
+	info := r.evaluationInfo(msg)
+	info.Duration = time.Since(start)
+	for _, h := range r.hooks {
+		h.OnEvaluateDone(ctx, info, res, err)
+	}
+}
+
+func (r *Propl[T]) evaluationInfo(msg T) EvaluationInfo {
+	return EvaluationInfo{
+		Message:  msg.ProtoReflect().Descriptor().FullName(),
+		Policies: len(r.policies),
+		Mode:     r.mode,
+	}
+}
+
+// NopHooks implements Hooks with methods that do nothing.
+type NopHooks struct{}
+
+func (NopHooks) OnEvaluateStart(ctx context.Context, _ EvaluationInfo) context.Context {
+	return ctx
+}
+
+func (NopHooks) OnPolicyStart(context.Context, PolicyInfo) {}
+
+func (NopHooks) OnPolicyResult(context.Context, PolicyInfo, PolicyResult) {}
+
+func (NopHooks) OnEvaluateDone(context.Context, EvaluationInfo, *Result, error) {}
+
+// MetricKey identifies the series a policy's metrics are recorded in.
+type MetricKey struct {
+	Message protoreflect.FullName
+	Path    string
+	Rule    string
+}
+
+// MetricsRecorder records policy metrics, e.g. as Prometheus or OpenTelemetry
+// counters and histograms labeled by the key.
+type MetricsRecorder interface {
+	// CountPolicy counts an evaluated policy by its action and whether it reported
+	// an infraction.
+	CountPolicy(key MetricKey, action Action, infraction bool)
+	// ObservePolicyLatency records how long a policy took to evaluate.
+	ObservePolicyLatency(key MetricKey, d time.Duration)
+}
+
+// MetricsHooks are Hooks that record each policy's result with the recorder.
+func MetricsHooks(recorder MetricsRecorder) Hooks {
+	return &metricsHooks{recorder: recorder}
+}
+
+type metricsHooks struct {
+	NopHooks
+	recorder MetricsRecorder
+}
+
+func (m *metricsHooks) OnPolicyResult(_ context.Context, policy PolicyInfo, result PolicyResult) {
+	key := MetricKey{Message: policy.Message, Path: policy.Path, Rule: policy.Rule}
+	m.recorder.CountPolicy(key, result.Action, result.Err != nil)
+	m.recorder.ObservePolicyLatency(key, result.Duration)
+}
+
+// conditionalPolicy is implemented by policies that are only evaluated when their
+// subject meets their conditions.
+type conditionalPolicy interface {
+	action() Action
+}
+
+// action reports how the policy is evaluated, without evaluating it.
+func (bp *boundPolicy) action() Action {
+	if !bp.guardsHold() {
+		return Skip
+	}
+	if cp, ok := bp.policy.(conditionalPolicy); ok {
+		return cp.action()
+	}
+	return Check
+}
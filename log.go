@@ -0,0 +1,81 @@
+package propl
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Keys of the attributes logged by LogHooks.
+const (
+	LogMessageKey  = "message"
+	LogPathKey     = "path"
+	LogPathsKey    = "paths"
+	LogCodeKey     = "code"
+	LogSeverityKey = "severity"
+	LogValueKey    = "value"
+	LogErrorKey    = "error"
+)
+
+// LogHooks are Hooks that log the outcome of each evaluation with the logger. Each
+// infraction and warning is logged with the message type, path, code, severity and
+// value, which is redacted if the field is sensitive. Infractions are logged at
+// LevelWarn, warnings at LevelInfo and infractions with SeverityInfo at LevelDebug.
+// An evaluation that could not complete is logged at LevelError, and one without
+// any infractions at LevelDebug.
+func LogHooks(logger *slog.Logger) Hooks {
+	return &logHooks{logger: logger}
+}
+
+type logHooks struct {
+	NopHooks
+	logger *slog.Logger
+}
+
+func (l *logHooks) OnEvaluateDone(ctx context.Context, evaluation EvaluationInfo, res *Result, err error) {
+	message := slog.String(LogMessageKey, string(evaluation.Message))
+	switch {
+	case err != nil:
+		l.logger.LogAttrs(ctx, slog.LevelError, "propl evaluation incomplete", message, slog.String(LogErrorKey, err.Error()))
+		return
+	case len(res.Infractions) == 0 && len(res.Warnings) == 0:
+		l.logger.LogAttrs(ctx, slog.LevelDebug, "propl evaluation passed", message)
+		return
+	}
+	for _, infractions := range []FieldInfractions{res.Infractions, res.Warnings} {
+		for _, fi := range infractions {
+			l.logInfraction(ctx, message, fi)
+		}
+	}
+}
+
+// logInfraction logs the infraction at the level of its severity.
+func (l *logHooks) logInfraction(ctx context.Context, message slog.Attr, fi FieldInfraction) {
+	attrs := []slog.Attr{
+		message,
+		slog.String(LogPathKey, fi.Path),
+		slog.String(LogSeverityKey, fi.Severity.String()),
+	}
+	if len(fi.Paths) > 0 {
+		attrs = append(attrs, slog.Any(LogPathsKey, fi.Paths))
+	}
+	if fi.Code != "" {
+		attrs = append(attrs, slog.String(LogCodeKey, fi.Code))
+	}
+	if fi.Value != "" {
+		attrs = append(attrs, slog.String(LogValueKey, fi.Value))
+	}
+	attrs = append(attrs, slog.String(LogErrorKey, fi.Err.Error()))
+	l.logger.LogAttrs(ctx, severityLevel(fi.Severity), "propl infraction", attrs...)
+}
+
+// severityLevel is the level infractions with severity s are logged at.
+func severityLevel(s Severity) slog.Level {
+	switch s {
+	case SeverityError:
+		return slog.LevelWarn
+	case SeverityWarning:
+		return slog.LevelInfo
+	default:
+		return slog.LevelDebug
+	}
+}
@@ -0,0 +1,231 @@
+package propl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ValidateMerged evaluates the resource policies against the resource an update would
+// produce: a copy of the stored resource returned by load, with the fields in the mask
+// copied from the request field at path. Fields in the mask that are not set in the
+// request are cleared. This catches updates whose fields are each valid but that leave
+// the resource invalid, e.g. by clearing a required field.
+//
+// The resource policies are evaluated with the mask paths relative to the resource, and
+// their infractions are reported on request paths, e.g. NeverZero("email") on a User is
+// reported on "user.email".
+func (r *Propl[T]) ValidateMerged(path string, load ResourceLoader, resource PolicySet, opts ...PolicyOption) *Propl[T] {
+	r.addPolicy(path, "ValidateMerged", "", func(store *fieldStore[T]) Policy {
+		return &mergedPolicy{
+			patch:     store.loadFieldsFromPath(path).getByPath(path),
+			path:      path,
+			maskPaths: store.mask(),
+			load:      load,
+			resource:  resource,
+		}
+	}, opts)
+	return r
+}
+
+// nestedInfractions is returned by policies that evaluate another policy set. Its
+// infractions are reported as they are, rather than as a single infraction on the
+// policy's path.
+type nestedInfractions struct {
+	result *Result
+}
+
+func (n *nestedInfractions) Error() string {
+	var msgs []string
+	for _, i := range append(n.result.Infractions, n.result.Warnings...) {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", i.Path, i.Err))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+var _ Policy = (*mergedPolicy)(nil)
+
+// mergedPolicy evaluates a resource policy set against the stored resource with the
+// masked fields of the patch applied.
+type mergedPolicy struct {
+	patch     *fieldData
+	path      string
+	maskPaths []string
+	load      ResourceLoader
+	resource  PolicySet
+}
+
+func (mp *mergedPolicy) Execute(ctx context.Context) error {
+	return mp.EvaluateSubjectTraits(ctx)
+}
+
+func (mp *mergedPolicy) loadsResource() {}
+
+func (mp *mergedPolicy) EvaluateSubjectTraits(ctx context.Context) error {
+	if isDryRun(ctx) {
+		return nil
+	}
+	existing, err := mp.load(ctx)
+	if err != nil {
+		return wrapRuleError(MessageLoadFailed, err)
+	}
+	if existing == nil {
+		return nil
+	}
+	merged := proto.Clone(existing)
+	var patch protoreflect.Message
+	if mp.patch != nil && mp.patch.s() {
+		patch, _ = mp.patch.v().(protoreflect.Message)
+	}
+	if patch == nil {
+		patch = merged.ProtoReflect().Type().Zero()
+	}
+	desc := merged.ProtoReflect().Descriptor()
+	if patch.Descriptor().FullName() != desc.FullName() {
+		return newRuleError(MessageMergeType, "patch", string(patch.Descriptor().FullName()), "resource", string(desc.FullName()))
+	}
+	maskPaths := relativeMaskPaths(mp.path, mp.maskPaths, desc)
+	// a mask without paths under the resource leaves it as stored
+	if len(maskPaths) > 0 || len(mp.maskPaths) == 0 {
+		applyMask(merged.ProtoReflect(), patch, maskPaths)
+	}
+	res, err := mp.resource.CheckMessage(ctx, merged, maskPaths...)
+	if err != nil {
+		return err
+	}
+	if len(res.Infractions) == 0 && len(res.Warnings) == 0 {
+		return nil
+	}
+	return &nestedInfractions{
+		result: &Result{
+			Infractions: prefixInfractions(mp.path, res.Infractions),
+			Warnings:    prefixInfractions(mp.path, res.Warnings),
+		},
+	}
+}
+
+// relativeMaskPaths makes mask paths relative to the resource at path, whose
+// descriptor is desc. Paths may already be relative, e.g. "email", or include the
+// resource field, e.g. "user.email". The resource field itself becomes "*". Paths
+// that are neither, e.g. "update_mask", are dropped.
+func relativeMaskPaths(path string, maskPaths []string, desc protoreflect.MessageDescriptor) []string {
+	relative := make([]string, 0, len(maskPaths))
+	for _, p := range maskPaths {
+		switch {
+		case p == path:
+			relative = append(relative, "*")
+		case strings.HasPrefix(p, path+"."):
+			relative = append(relative, strings.TrimPrefix(p, path+"."))
+		case p == "*" || fieldByName(desc, strings.Split(p, ".")[0]) != nil:
+			relative = append(relative, p)
+		}
+	}
+	return relative
+}
+
+// applyMask copies the fields at maskPaths from src to dst, clearing the fields in
+// dst that are not set in src. An empty mask replaces every field set in src, and a
+// "*" mask replaces dst with src.
+func applyMask(dst, src protoreflect.Message, maskPaths []string) {
+	if len(maskPaths) == 0 {
+		src.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+			dst.Clear(fd)
+			copyField(dst, src, fd)
+			return true
+		})
+		return
+	}
+	paths := append([]string(nil), maskPaths...)
+	// parents before their children, so "address.line1" is applied after "address"
+	sort.SliceStable(paths, func(i, j int) bool {
+		return strings.Count(paths[i], ".") < strings.Count(paths[j], ".")
+	})
+	for _, p := range paths {
+		if p == "*" {
+			proto.Reset(dst.Interface())
+			proto.Merge(dst.Interface(), src.Interface())
+			return
+		}
+	}
+	for _, p := range paths {
+		applyMaskPath(dst, src, strings.Split(p, "."))
+	}
+}
+
+func applyMaskPath(dst, src protoreflect.Message, segments []string) {
+	fd := dst.Descriptor().Fields().ByName(protoreflect.Name(segments[0]))
+	if fd == nil {
+		fd = dst.Descriptor().Fields().ByJSONName(segments[0])
+	}
+	if fd == nil {
+		return
+	}
+	if len(segments) == 1 {
+		dst.Clear(fd)
+		if src.Has(fd) {
+			copyField(dst, src, fd)
+		}
+		return
+	}
+	if fd.Message() == nil || fd.IsList() || fd.IsMap() || (!src.Has(fd) && !dst.Has(fd)) {
+		return
+	}
+	applyMaskPath(dst.Mutable(fd).Message(), src.Get(fd).Message(), segments[1:])
+}
+
+// copyField deep copies the field from src to dst, which must be the same message type.
+func copyField(dst, src protoreflect.Message, fd protoreflect.FieldDescriptor) {
+	v := src.Get(fd)
+	switch {
+	case fd.IsList():
+		dl := dst.Mutable(fd).List()
+		sl := v.List()
+		for i := 0; i < sl.Len(); i++ {
+			dl.Append(copyValue(sl.Get(i), fd))
+		}
+	case fd.IsMap():
+		dm := dst.Mutable(fd).Map()
+		v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+			dm.Set(k, copyValue(mv, fd.MapValue()))
+			return true
+		})
+	default:
+		dst.Set(fd, copyValue(v, fd))
+	}
+}
+
+func copyValue(v protoreflect.Value, fd protoreflect.FieldDescriptor) protoreflect.Value {
+	if fd.Message() == nil {
+		if b, ok := v.Interface().([]byte); ok {
+			return protoreflect.ValueOfBytes(append([]byte(nil), b...))
+		}
+		return v
+	}
+	return protoreflect.ValueOfMessage(proto.Clone(v.Message().Interface()).ProtoReflect())
+}
+
+// prefixInfractions reports infractions from a sub-message on paths relative to the
+// message at prefix. Group keys are rebuilt with the prefixed paths.
+func prefixInfractions(prefix string, infractions FieldInfractions) FieldInfractions {
+	prefixed := make(FieldInfractions, 0, len(infractions))
+	for _, fi := range infractions {
+		if len(fi.Paths) > 0 {
+			paths := make([]string, len(fi.Paths))
+			for i, p := range fi.Paths {
+				paths[i] = getPath(prefix, p)
+			}
+			if open := strings.Index(fi.Path, "("); open > 0 {
+				fi.Path = fmt.Sprintf("%s(%s)", fi.Path[:open], strings.Join(paths, ", "))
+			}
+			fi.Paths = paths
+		} else {
+			fi.Path = getPath(prefix, fi.Path)
+		}
+		prefixed = append(prefixed, fi)
+	}
+	return prefixed
+}
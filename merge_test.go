@@ -0,0 +1,87 @@
+package propl
+
+import (
+	"testing"
+
+	proplv1 "buf.build/gen/go/signal426/propl/protocolbuffers/go/propl/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestApplyMask(t *testing.T) {
+	stored := func() *proplv1.User {
+		return &proplv1.User{
+			Id:        "abc123",
+			FirstName: "bob",
+			PrimaryAddress: &proplv1.Address{
+				Line1: "a",
+				Line2: "b",
+			},
+			SecondaryAddresses: []*proplv1.Address{
+				{Line1: "x"},
+			},
+		}
+	}
+	patch := &proplv1.User{
+		LastName: "loblaw",
+		PrimaryAddress: &proplv1.Address{
+			Line1: "c",
+		},
+		SecondaryAddresses: []*proplv1.Address{
+			{Line1: "d"},
+		},
+	}
+
+	t.Run("it should copy and clear masked fields", func(t *testing.T) {
+		// arrange
+		dst := stored()
+		// act
+		applyMask(dst.ProtoReflect(), patch.ProtoReflect(), []string{"first_name", "last_name", "primary_address.line1", "secondary_addresses"})
+		// assert
+		assert.True(t, proto.Equal(&proplv1.User{
+			Id:       "abc123",
+			LastName: "loblaw",
+			PrimaryAddress: &proplv1.Address{
+				Line1: "c",
+				Line2: "b",
+			},
+			SecondaryAddresses: []*proplv1.Address{
+				{Line1: "d"},
+			},
+		}, dst))
+		dst.SecondaryAddresses[0].Line1 = "e"
+		assert.Equal(t, "d", patch.SecondaryAddresses[0].Line1, "the patch should not be aliased")
+	})
+
+	t.Run("it should replace set fields without a mask and replace with a wildcard", func(t *testing.T) {
+		// arrange
+		merged, replaced := stored(), stored()
+		// act
+		applyMask(merged.ProtoReflect(), patch.ProtoReflect(), nil)
+		applyMask(replaced.ProtoReflect(), patch.ProtoReflect(), []string{"*"})
+		// assert
+		assert.True(t, proto.Equal(&proplv1.User{
+			Id:        "abc123",
+			FirstName: "bob",
+			LastName:  "loblaw",
+			PrimaryAddress: &proplv1.Address{
+				Line1: "c",
+			},
+			SecondaryAddresses: []*proplv1.Address{
+				{Line1: "d"},
+			},
+		}, merged))
+		assert.True(t, proto.Equal(patch, replaced))
+	})
+}
+
+func TestRelativeMaskPaths(t *testing.T) {
+	t.Run("it should make paths relative to the resource and drop the others", func(t *testing.T) {
+		// arrange
+		desc := (&proplv1.User{}).ProtoReflect().Descriptor()
+		// act
+		paths := relativeMaskPaths("user", []string{"user.first_name", "last_name", "user", "update_mask", "users.id"}, desc)
+		// assert
+		assert.Equal(t, []string{"first_name", "last_name", "*"}, paths)
+	})
+}
@@ -0,0 +1,258 @@
+package propl
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Message IDs of the infractions reported by the built-in policies. The IDs are
+// stable, so catalogs can translate them. Template parameters are in braces.
+const (
+	// MessageNotZero has no parameters.
+	MessageNotZero = "propl.not_zero"
+	// MessageConditions has the parameter {conditions}.
+	MessageConditions = "propl.conditions"
+	// MessageFieldEqual and the other comparisons have the parameters {path} and {other}.
+	MessageFieldEqual       = "propl.field_equal"
+	MessageFieldNotEqual    = "propl.field_not_equal"
+	MessageFieldLessThan    = "propl.field_less_than"
+	MessageFieldGreaterThan = "propl.field_greater_than"
+	// MessageResourceNameType has the parameter {type}.
+	MessageResourceNameType = "propl.resource_name_type"
+	// MessageResourceNamePattern has the parameter {patterns}.
+	MessageResourceNamePattern = "propl.resource_name_pattern"
+	// MessageResourceName has no parameters.
+	MessageResourceName = "propl.resource_name"
+	// MessageResourceNameUndeclared has no parameters.
+	MessageResourceNameUndeclared = "propl.resource_name_undeclared"
+	// MessageAtLeastOneOf and the other groups have the parameter {paths}.
+	MessageAtLeastOneOf      = "propl.at_least_one_of"
+	MessageMutuallyExclusive = "propl.mutually_exclusive"
+	MessageAllOrNone         = "propl.all_or_none"
+	MessageExactlyOneOf      = "propl.exactly_one_of"
+	// MessageOutputOnlyInMask, MessageOutputOnlySet and MessageImmutableInMask have no parameters.
+	MessageOutputOnlyInMask = "propl.output_only_in_mask"
+	MessageOutputOnlySet    = "propl.output_only_set"
+	MessageImmutableInMask  = "propl.immutable_in_mask"
+	// MessageImmutable and MessageWriteOnce have no parameters.
+	MessageImmutable = "propl.immutable"
+	MessageWriteOnce = "propl.write_once"
+	// MessageLoadFailed has the parameter {error}.
+	MessageLoadFailed = "propl.load_failed"
+	// MessageIncomplete has the parameter {error}.
+	MessageIncomplete = "propl.incomplete"
+	// MessageMergeType has the parameters {patch} and {resource}.
+	MessageMergeType = "propl.merge_type"
+)
+
+// english is the built-in catalog. It is used for any message a catalog can't translate.
+var english = CatalogMap{
+	"en": {
+		MessageNotZero:                "it should not be zero",
+		MessageConditions:             "subject did not meet conditions {conditions}",
+		MessageFieldEqual:             "{path} should be equal to {other}",
+		MessageFieldNotEqual:          "{path} should not be equal to {other}",
+		MessageFieldLessThan:          "{path} should be less than {other}",
+		MessageFieldGreaterThan:       "{path} should be greater than {other}",
+		MessageResourceNameType:       "it should be a {type} resource name",
+		MessageResourceNamePattern:    "it should be a resource name matching {patterns}",
+		MessageResourceName:           "it should be a resource name",
+		MessageResourceNameUndeclared: "it should be a resource name, but no pattern is declared for it",
+		MessageAtLeastOneOf:           "at least one of {paths} must be set",
+		MessageMutuallyExclusive:      "only one of {paths} may be set",
+		MessageAllOrNone:              "either all or none of {paths} must be set",
+		MessageExactlyOneOf:           "exactly one of {paths} must be set",
+		MessageOutputOnlyInMask:       "it is output only and should not be in the mask",
+		MessageOutputOnlySet:          "it is output only and should not be set",
+		MessageImmutableInMask:        "it is immutable and should not be in the mask",
+		MessageImmutable:              "it is immutable and cannot be changed",
+		MessageWriteOnce:              "it has already been set and cannot be changed",
+		MessageLoadFailed:             "unable to load the existing resource: {error}",
+		MessageIncomplete:             "policy did not complete: {error}",
+		MessageMergeType:              "cannot apply {patch} to {resource}",
+	},
+}
+
+// English returns a copy of the built-in catalog, e.g. to start a catalog that
+// overrides some of its messages.
+func English() CatalogMap {
+	c := make(CatalogMap, len(english))
+	for locale, templates := range english {
+		c[locale] = make(map[string]string, len(templates))
+		for id, tmpl := range templates {
+			c[locale][id] = tmpl
+		}
+	}
+	return c
+}
+
+// Catalog translates message IDs to templates. Parameters in a template are
+// written in braces, e.g. "{path} should be less than {other}".
+type Catalog interface {
+	Template(locale language.Tag, id string) (string, bool)
+}
+
+// CatalogMap is a Catalog of templates by locale and message ID. A locale without a
+// template falls back to its parent, e.g. "fr-CA" to "fr".
+type CatalogMap map[string]map[string]string
+
+// Template implements Catalog.
+func (c CatalogMap) Template(locale language.Tag, id string) (string, bool) {
+	for t := locale; ; t = t.Parent() {
+		if tmpl, ok := c[t.String()][id]; ok {
+			return tmpl, true
+		}
+		if t == language.Und {
+			return "", false
+		}
+	}
+}
+
+// RuleError is an infraction with a stable message ID and the parameters of its
+// template. Its Error is rendered from the catalog and locale of the evaluation.
+type RuleError struct {
+	ID     string
+	Params map[string]string
+	// Err is the cause, if any
+	Err  error
+	text string
+	// custom is set when text is a message declared with WithMessage
+	custom bool
+}
+
+func newRuleError(id string, params ...string) *RuleError {
+	re := &RuleError{ID: id, Params: make(map[string]string, len(params)/2)}
+	for i := 0; i+1 < len(params); i += 2 {
+		re.Params[params[i]] = params[i+1]
+	}
+	return re
+}
+
+// wrapRuleError is newRuleError for infractions caused by err, which is also the
+// {error} parameter.
+func wrapRuleError(id string, err error) *RuleError {
+	re := newRuleError(id, "error", err.Error())
+	re.Err = err
+	return re
+}
+
+func (e *RuleError) Error() string {
+	if e.text != "" {
+		return e.text
+	}
+	tmpl, _ := english.Template(language.English, e.ID)
+	return render(tmpl, e.Params)
+}
+
+func (e *RuleError) Unwrap() error {
+	return e.Err
+}
+
+// localize renders the error from the catalog in the first locale it has a
+// template for, falling back to English.
+func (e *RuleError) localize(catalog Catalog, locales []language.Tag) *RuleError {
+	if e.custom {
+		return e
+	}
+	for _, l := range locales {
+		if tmpl, ok := catalog.Template(l, e.ID); ok {
+			localized := *e
+			localized.text = render(tmpl, e.Params)
+			return &localized
+		}
+	}
+	return e
+}
+
+// withMessage replaces the error's message with the message declared for its policy,
+// rendered with the error's parameters.
+func withMessage(err error, message string) error {
+	re, ok := err.(*RuleError)
+	if !ok {
+		return &RuleError{Err: err, text: message, custom: true}
+	}
+	custom := *re
+	custom.text = render(message, re.Params)
+	custom.custom = true
+	return &custom
+}
+
+// render replaces each {name} in the template with its parameter.
+func render(tmpl string, params map[string]string) string {
+	if len(params) == 0 {
+		return tmpl
+	}
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	replacements := make([]string, 0, len(params)*2)
+	for _, name := range names {
+		replacements = append(replacements, "{"+name+"}", params[name])
+	}
+	return strings.NewReplacer(replacements...).Replace(tmpl)
+}
+
+type localeKey struct{}
+
+// WithLocale returns a context that selects the locales infraction messages are
+// rendered in, in order of preference.
+func WithLocale(ctx context.Context, locales ...language.Tag) context.Context {
+	return context.WithValue(ctx, localeKey{}, locales)
+}
+
+// WithAcceptLanguage is WithLocale for the locales of an Accept-Language header, e.g.
+// from the "accept-language" gRPC metadata. Invalid headers select no locales.
+func WithAcceptLanguage(ctx context.Context, header string) context.Context {
+	tags, _, _ := language.ParseAcceptLanguage(header)
+	return WithLocale(ctx, tags...)
+}
+
+// localesFromContext returns the locales selected by ctx.
+func localesFromContext(ctx context.Context) []language.Tag {
+	locales, _ := ctx.Value(localeKey{}).([]language.Tag)
+	return locales
+}
+
+// WithCatalog renders infraction messages from the catalog in the locale selected by
+// the evaluation context. Messages the catalog can't translate are rendered in English.
+func (r *Propl[T]) WithCatalog(c Catalog) *Propl[T] {
+	r.catalog = c
+	return r
+}
+
+// infractionError is the error a policy's infraction is reported with: its declared
+// message, if any, localized for the evaluation.
+func (r *Propl[T]) infractionError(ctx context.Context, bp *boundPolicy, err error) error {
+	if bp.options.message != "" {
+		err = withMessage(err, bp.options.message)
+	}
+	re, ok := err.(*RuleError)
+	if !ok || r.catalog == nil {
+		return err
+	}
+	if locales := localesFromContext(ctx); len(locales) > 0 {
+		return re.localize(r.catalog, locales)
+	}
+	return err
+}
+
+// localizeInfractions renders the rule errors of the infractions from the catalog.
+func (r *Propl[T]) localizeInfractions(ctx context.Context, infractions FieldInfractions) {
+	if r.catalog == nil {
+		return
+	}
+	locales := localesFromContext(ctx)
+	if len(locales) == 0 {
+		return
+	}
+	for i, fi := range infractions {
+		if re, ok := fi.Err.(*RuleError); ok {
+			infractions[i].Err = re.localize(r.catalog, locales)
+		}
+	}
+}
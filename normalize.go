@@ -0,0 +1,194 @@
+package propl
+
+import (
+	"math"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Normalizer rewrites a field's value before the policies are evaluated. It receives
+// the field's descriptor and current value, and returns the value to set. Values it
+// doesn't apply to should be returned unchanged.
+type Normalizer func(fd protoreflect.FieldDescriptor, v protoreflect.Value) protoreflect.Value
+
+// normalization is a Normalizer declared on a path.
+type normalization struct {
+	path string
+	fn   Normalizer
+}
+
+// Normalize rewrites the field at the provided path with fn before the precheck and
+// policies are evaluated, e.g. so that NeverZero fails on a name of only whitespace. Normalizers run
+// in declaration order, and only on fields that are set and, when there is a mask, in
+// the mask. The evaluated message is modified. Normalizers of repeated fields are
+// applied to each element.
+func (r *Propl[T]) Normalize(path string, fn Normalizer) *Propl[T] {
+	r.normalizations = append(r.normalizations, &normalization{path: path, fn: fn})
+	return r
+}
+
+// normalize applies the declared normalizers to msg.
+func (r *Propl[T]) normalize(msg T, maskPaths []string) {
+	if len(r.normalizations) == 0 {
+		return
+	}
+	store := newFieldStore(msg, maskPaths...)
+	for _, n := range r.normalizations {
+		if len(maskPaths) > 0 && !store.isFieldInMask(n.path) {
+			continue
+		}
+		parent, fd, ok := resolveSetField(msg, n.path)
+		if !ok {
+			continue
+		}
+		switch {
+		case fd.IsList():
+			list := parent.Mutable(fd).List()
+			for i := 0; i < list.Len(); i++ {
+				list.Set(i, n.fn(fd, list.Get(i)))
+			}
+		case fd.IsMap():
+			m := parent.Mutable(fd).Map()
+			m.Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				m.Set(k, n.fn(fd.MapValue(), mv))
+				return true
+			})
+		default:
+			parent.Set(fd, n.fn(fd, parent.Get(fd)))
+		}
+	}
+}
+
+// resolveSetField resolves the "." delimited path to the field and its parent message
+// when the field and every message along the path are set.
+func resolveSetField(msg proto.Message, path string) (protoreflect.Message, protoreflect.FieldDescriptor, bool) {
+	if isNilMessage(msg) {
+		return nil, nil, false
+	}
+	m := msg.ProtoReflect()
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := fieldByName(m.Descriptor(), name)
+		if fd == nil || !m.Has(fd) {
+			return nil, nil, false
+		}
+		if i == len(names)-1 {
+			return m, fd, true
+		}
+		if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return nil, nil, false
+		}
+		m = m.Mutable(fd).Message()
+	}
+	return nil, nil, false
+}
+
+// fieldByName finds the field by its proto or JSON name.
+func fieldByName(desc protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	if fd := desc.Fields().ByName(protoreflect.Name(name)); fd != nil {
+		return fd
+	}
+	return desc.Fields().ByJSONName(name)
+}
+
+// isNilMessage reports whether msg is nil or a typed nil pointer.
+func isNilMessage(msg proto.Message) bool {
+	return msg == nil || !msg.ProtoReflect().IsValid()
+}
+
+// stringNormalizer adapts a string function to a Normalizer of string fields.
+func stringNormalizer(f func(string) string) Normalizer {
+	return func(fd protoreflect.FieldDescriptor, v protoreflect.Value) protoreflect.Value {
+		if fd.Kind() != protoreflect.StringKind {
+			return v
+		}
+		return protoreflect.ValueOfString(f(v.String()))
+	}
+}
+
+// TrimSpace removes leading and trailing white space from strings.
+func TrimSpace() Normalizer {
+	return stringNormalizer(strings.TrimSpace)
+}
+
+// ToLower maps strings to lower case, e.g. for email addresses.
+func ToLower() Normalizer {
+	return stringNormalizer(strings.ToLower)
+}
+
+// NFC normalizes strings to Unicode Normalization Form C, so that equivalent strings
+// compare equal.
+func NFC() Normalizer {
+	return stringNormalizer(norm.NFC.String)
+}
+
+// CollapseWhitespace replaces each run of white space in strings with a single space
+// and trims leading and trailing white space.
+func CollapseWhitespace() Normalizer {
+	return stringNormalizer(func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	})
+}
+
+// Clamp limits numbers to the range [min, max]. Integer fields are clamped to the
+// bounds converted to the field's type, and bounds outside the type's range are
+// limited to it, e.g. a negative min is 0 for unsigned fields.
+func Clamp(min, max float64) Normalizer {
+	return func(fd protoreflect.FieldDescriptor, v protoreflect.Value) protoreflect.Value {
+		var f float64
+		switch fd.Kind() {
+		case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+			protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+			f = float64(v.Int())
+		case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+			f = float64(v.Uint())
+		case protoreflect.FloatKind, protoreflect.DoubleKind:
+			f = v.Float()
+		default:
+			return v
+		}
+		switch {
+		case f < min:
+			return numberValue(fd.Kind(), min)
+		case f > max:
+			return numberValue(fd.Kind(), max)
+		default:
+			return v
+		}
+	}
+}
+
+// numberValue converts f to a value of the numeric kind. Integers saturate at the
+// limits of their kind, since converting a float outside them is undefined.
+func numberValue(kind protoreflect.Kind, f float64) protoreflect.Value {
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(int32(math.Max(math.MinInt32, math.Min(math.MaxInt32, f))))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// float64(math.MaxInt64) rounds up to 2^63, which is out of range
+		switch {
+		case f >= math.MaxInt64:
+			return protoreflect.ValueOfInt64(math.MaxInt64)
+		case f <= math.MinInt64:
+			return protoreflect.ValueOfInt64(math.MinInt64)
+		}
+		return protoreflect.ValueOfInt64(int64(f))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(uint32(math.Max(0, math.Min(math.MaxUint32, f))))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		switch {
+		case f >= math.MaxUint64:
+			return protoreflect.ValueOfUint64(math.MaxUint64)
+		case f <= 0:
+			return protoreflect.ValueOfUint64(0)
+		}
+		return protoreflect.ValueOfUint64(uint64(f))
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(float32(f))
+	default:
+		return protoreflect.ValueOfFloat64(f)
+	}
+}
@@ -0,0 +1,75 @@
+package propl
+
+import "fmt"
+
+// PolicyOption configures a single declared policy.
+type PolicyOption func(o *policyOptions)
+
+type policyOptions struct {
+	severity Severity
+	guards   []*guard
+	message  string
+	code     string
+}
+
+// identity describes the options for duplicate detection.
+func (o *policyOptions) identity() string {
+	return fmt.Sprintf("%d %q %q", o.severity, o.code, o.message)
+}
+
+func newPolicyOptions(opts []PolicyOption) *policyOptions {
+	o := &policyOptions{
+		severity: SeverityError,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithSeverity sets the severity of the policy's infractions. Only infractions
+// with SeverityError fail evaluation. Defaults to SeverityError.
+func WithSeverity(s Severity) PolicyOption {
+	return func(o *policyOptions) {
+		o.severity = s
+	}
+}
+
+// WithMessage replaces the message of the policy's infractions. The message may use
+// the parameters of the policy's template, e.g. "{path} must be before {other}". It is
+// not translated by a catalog.
+func WithMessage(message string) PolicyOption {
+	return func(o *policyOptions) {
+		o.message = message
+	}
+}
+
+// WithCode sets a stable code, e.g. "USER_ID_REQUIRED", that is reported on the
+// policy's infractions so clients can tell them apart without matching messages.
+func WithCode(code string) PolicyOption {
+	return func(o *policyOptions) {
+		o.code = code
+	}
+}
+
+// When only evaluates the policy if the predicate holds for the field at path.
+// The field is resolved from the same message as the policy's own field, e.g.
+// to require shipping_address only when delivery_method is SHIP:
+//
+//	NeverZero("shipping_address", When("delivery_method", Equals(v1.DeliveryMethod_SHIP)))
+//
+// A policy may have more than one When, in which case they must all hold.
+func When(path string, predicate Predicate) PolicyOption {
+	return func(o *policyOptions) {
+		o.guards = append(o.guards, &guard{
+			path:      path,
+			predicate: predicate,
+		})
+	}
+}
+
+// guard is a When condition.
+type guard struct {
+	path      string
+	predicate Predicate
+}
@@ -0,0 +1,166 @@
+// Package otel traces propl evaluations and publishes violation metrics with
+// OpenTelemetry.
+//
+// Instrumentation implements propl.Hooks. Installed on a policy set, it traces each
+// evaluation in a span of its own, a child of any span in the evaluation context, and
+// counts evaluations and violations:
+//
+//	inst, err := otel.New()
+//	err = propl.For(msg).NeverZero("user.id").WithHooks(inst).E(ctx)
+package otel
+
+import (
+	"context"
+
+	"github.com/signal426/propl"
+	global "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// ScopeName is the instrumentation scope of the tracer and meter.
+const ScopeName = "github.com/signal426/propl/otel"
+
+// Attribute keys recorded on spans, events and metrics.
+const (
+	MessageKey     = attribute.Key("propl.message")
+	PoliciesKey    = attribute.Key("propl.policies")
+	InfractionsKey = attribute.Key("propl.infractions")
+	WarningsKey    = attribute.Key("propl.warnings")
+	ModeKey        = attribute.Key("propl.mode")
+	PathKey        = attribute.Key("propl.path")
+	CodeKey        = attribute.Key("propl.code")
+	SeverityKey    = attribute.Key("propl.severity")
+	ValueKey       = attribute.Key("propl.value")
+	OutcomeKey     = attribute.Key("propl.outcome")
+	ErrorKey       = attribute.Key("propl.error")
+)
+
+// Option configures Instrumentation.
+type Option func(*config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// WithTracerProvider sets the tracer provider. Defaults to the global provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithMeterProvider sets the meter provider. Defaults to the global provider.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = mp
+	}
+}
+
+// Instrumentation traces and measures evaluations.
+type Instrumentation struct {
+	propl.NopHooks
+	tracer      trace.Tracer
+	evaluations metric.Int64Counter
+	violations  metric.Int64Counter
+}
+
+var _ propl.Hooks = (*Instrumentation)(nil)
+
+// New creates the instrumentation and its instruments.
+func New(opts ...Option) (*Instrumentation, error) {
+	c := &config{
+		tracerProvider: global.GetTracerProvider(),
+		meterProvider:  global.GetMeterProvider(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	meter := c.meterProvider.Meter(ScopeName)
+	evaluations, err := meter.Int64Counter("propl.evaluations",
+		metric.WithDescription("Evaluations of policy sets by message and outcome."))
+	if err != nil {
+		return nil, err
+	}
+	violations, err := meter.Int64Counter("propl.violations",
+		metric.WithDescription("Infractions and warnings by message, path, code and severity."))
+	if err != nil {
+		return nil, err
+	}
+	return &Instrumentation{
+		tracer:      c.tracerProvider.Tracer(ScopeName),
+		evaluations: evaluations,
+		violations:  violations,
+	}, nil
+}
+
+type spanKey struct{}
+
+// OnEvaluateStart implements propl.Hooks. It starts the span of the evaluation.
+func (i *Instrumentation) OnEvaluateStart(ctx context.Context, evaluation propl.EvaluationInfo) context.Context {
+	ctx, span := i.tracer.Start(ctx, "propl.Evaluate")
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// OnEvaluateDone implements propl.Hooks. It records the evaluation on the span started
+// by OnEvaluateStart, with an event for each infraction and warning, ends the span and
+// counts the evaluation and its violations. Spans started by others are left as they are.
+func (i *Instrumentation) OnEvaluateDone(ctx context.Context, evaluation propl.EvaluationInfo, res *propl.Result, err error) {
+	message := MessageKey.String(string(evaluation.Message))
+	span, ok := ctx.Value(spanKey{}).(trace.Span)
+	if !ok {
+		// not started by this instrumentation, so only count the evaluation
+		span = noop.Span{}
+	}
+	defer span.End()
+	span.SetAttributes(
+		message,
+		PoliciesKey.Int(evaluation.Policies),
+		ModeKey.String(evaluation.Mode.String()),
+	)
+	outcome := "pass"
+	switch {
+	case err != nil:
+		outcome = "error"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case res.Failed():
+		outcome = "fail"
+		span.SetStatus(codes.Error, "infractions")
+	}
+	i.evaluations.Add(ctx, 1, metric.WithAttributes(message, OutcomeKey.String(outcome)))
+	if res == nil {
+		return
+	}
+	span.SetAttributes(
+		InfractionsKey.Int(len(res.Infractions)),
+		WarningsKey.Int(len(res.Warnings)),
+	)
+	for _, infractions := range []propl.FieldInfractions{res.Infractions, res.Warnings} {
+		for _, fi := range infractions {
+			i.recordInfraction(ctx, span, message, fi)
+		}
+	}
+}
+
+// recordInfraction adds an event for the infraction to the span and counts it.
+func (i *Instrumentation) recordInfraction(ctx context.Context, span trace.Span, message attribute.KeyValue, fi propl.FieldInfraction) {
+	attrs := []attribute.KeyValue{
+		message,
+		PathKey.String(fi.Path),
+		SeverityKey.String(fi.Severity.String()),
+	}
+	if fi.Code != "" {
+		attrs = append(attrs, CodeKey.String(fi.Code))
+	}
+	i.violations.Add(ctx, 1, metric.WithAttributes(attrs...))
+	event := append(attrs[1:len(attrs):len(attrs)], ErrorKey.String(fi.Err.Error()))
+	if fi.Value != "" {
+		event = append(event, ValueKey.String(fi.Value))
+	}
+	span.AddEvent("propl.infraction", trace.WithAttributes(event...))
+}
@@ -0,0 +1,125 @@
+package otel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/signal426/propl"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestInstrumentation(t *testing.T) {
+	newInstrumentation := func(t *testing.T) (*Instrumentation, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+		spans := tracetest.NewSpanRecorder()
+		reader := sdkmetric.NewManualReader()
+		inst, err := New(
+			WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+			WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return inst, spans, reader
+	}
+
+	t.Run("it should record the evaluation on a span", func(t *testing.T) {
+		// arrange
+		inst, spans, _ := newInstrumentation(t)
+		msg := wrapperspb.String("bob")
+		p := propl.For(msg).
+			FieldPolicy("value", propl.ResourceName("users/{user}"), propl.InMessage, propl.WithCode("INVALID_NAME")).
+			NeverZero("value").
+			WithHooks(inst)
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.Error(t, err)
+		ended := spans.Ended()
+		assert.Len(t, ended, 1)
+		span := ended[0]
+		assert.Equal(t, "propl.Evaluate", span.Name())
+		assert.Equal(t, codes.Error, span.Status().Code)
+		assert.ElementsMatch(t, []attribute.KeyValue{
+			MessageKey.String("google.protobuf.StringValue"),
+			PoliciesKey.Int(2),
+			ModeKey.String("CollectAll"),
+			InfractionsKey.Int(1),
+			WarningsKey.Int(0),
+		}, span.Attributes())
+		assert.Len(t, span.Events(), 1)
+		assert.Equal(t, "propl.infraction", span.Events()[0].Name)
+		assert.ElementsMatch(t, []attribute.KeyValue{
+			PathKey.String("value"),
+			SeverityKey.String("error"),
+			CodeKey.String("INVALID_NAME"),
+			ValueKey.String("bob"),
+			ErrorKey.String("it should be a resource name matching users/{user}"),
+		}, span.Events()[0].Attributes)
+	})
+
+	t.Run("it should not annotate the caller's span", func(t *testing.T) {
+		// arrange
+		inst, spans, _ := newInstrumentation(t)
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)).Tracer("caller")
+		ctx, rpc := tracer.Start(context.Background(), "rpc")
+		p := propl.For(wrapperspb.String("")).NeverZero("value").WithHooks(inst)
+		// act
+		err := p.E(ctx)
+		rpc.End()
+		// assert
+		assert.Error(t, err)
+		ended := spans.Ended()
+		assert.Len(t, ended, 2)
+		evaluation, caller := ended[0], ended[1]
+		assert.Equal(t, "propl.Evaluate", evaluation.Name())
+		assert.Equal(t, caller.SpanContext().SpanID(), evaluation.Parent().SpanID())
+		assert.Equal(t, codes.Error, evaluation.Status().Code)
+		assert.Equal(t, codes.Unset, caller.Status().Code)
+		assert.Empty(t, caller.Attributes())
+		assert.Empty(t, caller.Events())
+	})
+
+	t.Run("it should count evaluations and violations", func(t *testing.T) {
+		// arrange
+		inst, _, reader := newInstrumentation(t)
+		p := propl.For[*wrapperspb.StringValue](nil).
+			NeverZero("value", propl.WithSeverity(propl.SeverityWarning)).
+			WithHooks(inst)
+		// act
+		_, invalidErr := p.Check(context.Background())
+		res, err := propl.For(wrapperspb.String("bob")).NeverZero("value").WithHooks(inst).Check(context.Background())
+		var rm metricdata.ResourceMetrics
+		collectErr := reader.Collect(context.Background(), &rm)
+		// assert
+		assert.NoError(t, invalidErr)
+		assert.NoError(t, err)
+		assert.NoError(t, collectErr)
+		assert.False(t, res.Failed())
+		sums := make(map[string]metricdata.Sum[int64])
+		for _, m := range rm.ScopeMetrics[0].Metrics {
+			sums[m.Name] = m.Data.(metricdata.Sum[int64])
+		}
+		message := MessageKey.String("google.protobuf.StringValue")
+		assert.ElementsMatch(t, []metricdata.DataPoint[int64]{
+			{Attributes: attribute.NewSet(message, OutcomeKey.String("pass")), Value: 2},
+		}, withoutTimes(sums["propl.evaluations"].DataPoints))
+		assert.ElementsMatch(t, []metricdata.DataPoint[int64]{
+			{Attributes: attribute.NewSet(message, PathKey.String("value"), SeverityKey.String("warning")), Value: 1},
+		}, withoutTimes(sums["propl.violations"].DataPoints))
+	})
+}
+
+func withoutTimes(points []metricdata.DataPoint[int64]) []metricdata.DataPoint[int64] {
+	for i := range points {
+		points[i].StartTime, points[i].Time = time.Time{}, time.Time{}
+	}
+	return points
+}
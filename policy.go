@@ -23,50 +23,74 @@ type Trait interface {
 	Valid() bool
 }
 
+// Policy is a policy bound to the message being evaluated. Both methods receive the
+// evaluation context, which is done when the evaluation is cancelled or the policy
+// exceeds its timeout.
 type Policy interface {
-	Execute() error
-	EvaluateSubjectTraits() error
+	Execute(ctx context.Context) error
+	EvaluateSubjectTraits(ctx context.Context) error
 }
 
 type policy struct {
 	subject    Subject
 	conditions Condition
 	traits     Trait
+	// trace records the traits checked, when explaining an evaluation
+	trace *[]TraitTrace
 }
 
 // Execute checks traits on the field based on the conditional action signal
 // returned from the subject.
-func (p *policy) Execute() error {
+func (p *policy) Execute(ctx context.Context) error {
 	switch p.subject.ConditionalAction(p.conditions) {
 	case Skip:
 		return nil
 	case Fail:
-		return fmt.Errorf("subject did not meet conditions %s", p.conditions.FlagsString())
+		return newRuleError(MessageConditions, "conditions", p.conditions.FlagsString())
 	default:
-		return p.EvaluateSubjectTraits()
+		return p.EvaluateSubjectTraits(ctx)
 	}
 }
 
-func (p *policy) EvaluateSubjectTraits() error {
-	return p.checkTraits(p.traits)
+func (p *policy) action() Action {
+	return p.subject.ConditionalAction(p.conditions)
 }
 
-func (p *policy) checkTraits(t Trait) error {
+// policyIdentity describes a policy with the traits and conditions such that two
+// identical declarations on the same path share an identity.
+func policyIdentity(conditions Condition, traits Trait) string {
+	return fmt.Sprintf("%d %s", conditions, traitIdentity(traits))
+}
+
+func (p *policy) EvaluateSubjectTraits(_ context.Context) error {
+	return p.checkTraits(p.traits, "")
+}
+
+// checkTraits checks the chain starting at t, which follows the previous trait
+// by link.
+func (p *policy) checkTraits(t Trait, link string) error {
 	if t == nil {
 		return nil
 	}
-	if t.Valid() && !p.subject.HasTrait(t) {
+	held := !t.Valid() || p.subject.HasTrait(t)
+	if p.trace != nil && t.Valid() {
+		*p.trace = append(*p.trace, traceTrait(t, link, held))
+	}
+	if !held {
 		// if we have an or, keep going
 		if t.Or().Valid() {
-			return p.checkTraits(t.Or())
+			return p.checkTraits(t.Or(), "or")
 		}
 		// else, we're done checking
+		if ct, ok := t.(*trait); ok {
+			return ct.infraction()
+		}
 		return errors.New(t.InfractionsString())
 	}
 	// if there's an and condition, keep going
 	// else, we're done
 	if t.And().Valid() {
-		return p.checkTraits(t.And())
+		return p.checkTraits(t.And(), "and")
 	}
 	return nil
 }
@@ -75,20 +99,24 @@ type customPolicy[T proto.Message] struct {
 	arg        T
 	subject    Subject
 	conditions Condition
-	f          func(t T) error
+	f          func(ctx context.Context, t T) error
 }
 
-func (mp *customPolicy[T]) Execute() error {
+func (mp *customPolicy[T]) Execute(ctx context.Context) error {
 	switch mp.subject.ConditionalAction(mp.conditions) {
 	case Skip:
 		return nil
 	case Fail:
-		return fmt.Errorf("subject did not meet conditions %s", mp.conditions.FlagsString())
+		return newRuleError(MessageConditions, "conditions", mp.conditions.FlagsString())
 	default:
-		return mp.EvaluateSubjectTraits()
+		return mp.EvaluateSubjectTraits(ctx)
 	}
 }
 
-func (mp *customPolicy[T]) EvaluateSubjectTraits() error {
-	return mp.f(mp.arg)
+func (mp *customPolicy[T]) action() Action {
+	return mp.subject.ConditionalAction(mp.conditions)
+}
+
+func (mp *customPolicy[T]) EvaluateSubjectTraits(ctx context.Context) error {
+	return mp.f(ctx, mp.arg)
 }
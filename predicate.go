@@ -0,0 +1,111 @@
+package propl
+
+import (
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Predicate reports whether a field's state satisfies some condition. set is false
+// when the field is not present in the message, in which case value is invalid.
+type Predicate func(value protoreflect.Value, set bool) bool
+
+// IsSet holds when the field is present in the message.
+func IsSet() Predicate {
+	return func(_ protoreflect.Value, set bool) bool {
+		return set
+	}
+}
+
+// IsUnset holds when the field is not present in the message.
+func IsUnset() Predicate {
+	return func(_ protoreflect.Value, set bool) bool {
+		return !set
+	}
+}
+
+// Equals holds when the field is set to v. v may be a Go scalar, an enum
+// value or a message.
+func Equals(v any) Predicate {
+	return func(value protoreflect.Value, set bool) bool {
+		return set && valueEquals(value, v)
+	}
+}
+
+// NotEquals holds when the field is not set to v.
+func NotEquals(v any) Predicate {
+	return func(value protoreflect.Value, set bool) bool {
+		return !set || !valueEquals(value, v)
+	}
+}
+
+// OneOf holds when the field is set to any of vs.
+func OneOf(vs ...any) Predicate {
+	return func(value protoreflect.Value, set bool) bool {
+		for _, v := range vs {
+			if set && valueEquals(value, v) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// valueEquals compares a field's value to a Go value. Numbers are compared by
+// value regardless of their width, so an int can be compared to an int32 field.
+func valueEquals(value protoreflect.Value, v any) bool {
+	if !value.IsValid() {
+		return false
+	}
+	switch x := v.(type) {
+	case protoreflect.Enum:
+		n, ok := value.Interface().(protoreflect.EnumNumber)
+		return ok && n == x.Number()
+	case proto.Message:
+		m, ok := value.Interface().(protoreflect.Message)
+		return ok && proto.Equal(m.Interface(), x)
+	}
+	fv := reflect.ValueOf(value.Interface())
+	rv := reflect.ValueOf(v)
+	switch {
+	case isInt(fv) && isInt(rv):
+		return fv.Int() == rv.Int()
+	case isUint(fv) && isUint(rv):
+		return fv.Uint() == rv.Uint()
+	case isInt(fv) && isUint(rv):
+		return fv.Int() >= 0 && uint64(fv.Int()) == rv.Uint()
+	case isUint(fv) && isInt(rv):
+		return rv.Int() >= 0 && fv.Uint() == uint64(rv.Int())
+	case isFloat(fv) && (isFloat(rv) || isInt(rv)):
+		return fv.Float() == toFloat(rv)
+	}
+	return reflect.DeepEqual(value.Interface(), v)
+}
+
+func isInt(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isUint(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func isFloat(v reflect.Value) bool {
+	return v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64
+}
+
+func toFloat(v reflect.Value) float64 {
+	if isInt(v) {
+		return float64(v.Int())
+	}
+	return v.Float()
+}
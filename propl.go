@@ -2,31 +2,108 @@ package propl
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // Propl is an aggregation of policies on some proto message.
 type Propl[T proto.Message] struct {
-	policies                map[string]Policy
-	fieldStore              *fieldStore[T]
+	msg                     T
+	maskPaths               []string
+	policies                []*declaredPolicy[T]
 	fieldInfractionsHandler FieldInfractionsHandler
+	orderedHandler          OrderedFieldInfractionsHandler
 	precheck                Precheck[T]
+	concurrency             int
+	policyTimeout           time.Duration
+	detectDuplicates        bool
+	mode                    EvaluationMode
+	maxInfractions          int
+	warningsHandler         WarningsHandler
+	normalizations          []*normalization
+	defaults                []*defaultValue
+	sensitive               []string
+	catalog                 Catalog
+	hooks                   []Hooks
+	subMessages             []*subMessages
+	// errs are errors in the declarations, reported by Err
+	errs []error
+	// desc is the descriptor of the messages evaluated, if declared with ForDescriptor
+	desc protoreflect.MessageDescriptor
 }
 
 // For creates a new policy aggregate for the specified message that can be built upon using the
 // builder methods.
+//
+// Policies are bound to the message's fields when they are evaluated, so a policy aggregate
+// can also be declared once (e.g. For[*v1.User](nil)) and evaluated against any message of
+// its type using CheckMessage.
 func For[T proto.Message](msg T, paths ...string) *Propl[T] {
 	r := &Propl[T]{
-		fieldStore: newFieldStore(msg, paths...),
-		policies:   make(map[string]Policy),
+		msg:       msg,
+		maskPaths: paths,
 	}
 	return r
 }
 
+// ForDescriptor creates a policy aggregate for messages of the descriptor, e.g. dynamicpb
+// messages built from a descriptor set, when their Go type is not known at compile time.
+// Evaluate messages using CheckMessage or CheckReflect. Paths, masks and traits work as
+// they do for generated messages.
+func ForDescriptor(desc protoreflect.MessageDescriptor) *Propl[proto.Message] {
+	return &Propl[proto.Message]{desc: desc}
+}
+
+// errNoMessage reports that policies declared with ForDescriptor, or for a nil message
+// when T is an interface, have no message of their own to evaluate.
+func (r *Propl[T]) errNoMessage() error {
+	switch {
+	case r.desc != nil:
+		return fmt.Errorf("policies for %s have no message to evaluate, use CheckMessage", r.desc.FullName())
+	case any(r.msg) == nil:
+		return errors.New("policies declared for a nil message have no message to evaluate, use CheckMessage")
+	default:
+		return nil
+	}
+}
+
+// descriptor is the descriptor of the messages the policies are evaluated against. It
+// is unknown for policies declared for a nil message when T is an interface.
+func (r *Propl[T]) descriptor() (protoreflect.MessageDescriptor, error) {
+	if r.desc != nil {
+		return r.desc, nil
+	}
+	if any(r.msg) == nil {
+		return nil, errors.New("policies declared for a nil message have no descriptor, use ForDescriptor")
+	}
+	return r.msg.ProtoReflect().Descriptor(), nil
+}
+
 // WithInfractionsHandler specify how to handle the infractions map (map[string]error) if there are any
 func (r *Propl[T]) WithFieldInfractionsHandler(f FieldInfractionsHandler) *Propl[T] {
 	r.fieldInfractionsHandler = f
+	r.orderedHandler = nil
+	return r
+}
+
+// WithOrderedFieldInfractionsHandler specify how to handle the infractions if there are any. Unlike
+// the map passed to a FieldInfractionsHandler, the infractions are in policy declaration order.
+func (r *Propl[T]) WithOrderedFieldInfractionsHandler(f OrderedFieldInfractionsHandler) *Propl[T] {
+	r.orderedHandler = f
+	r.fieldInfractionsHandler = nil
+	return r
+}
+
+// WithWarningsHandler is called with the infractions below SeverityError whenever
+// there are any. Use it to log or count requests that a policy would reject before
+// making the policy enforcing.
+func (r *Propl[T]) WithWarningsHandler(f WarningsHandler) *Propl[T] {
+	r.warningsHandler = f
 	return r
 }
 
@@ -37,65 +114,237 @@ func (r *Propl[T]) WithPrecheckPolicy(p Precheck[T]) *Propl[T] {
 	return r
 }
 
-func (r *Propl[T]) FieldPolicy(path string, traits Trait, conditions Condition) *Propl[T] {
-	r.policies[path] = &policy{
-		conditions: conditions,
-		traits:     traits,
+// WithConcurrency evaluates up to limit policies at a time. Useful when custom evaluators
+// are I/O bound (e.g. uniqueness checks against a database). A limit less than 2
+// evaluates policies serially, which is the default.
+func (r *Propl[T]) WithConcurrency(limit int) *Propl[T] {
+	r.concurrency = limit
+	return r
+}
+
+// WithPolicyTimeout bounds how long a single policy may run. A policy that does not
+// complete in time is reported as an infraction on its path.
+//
+// The policy's context is done once the timeout elapses, and custom evaluators must
+// honor it: evaluation waits for each policy to return, so one that ignores its
+// context holds up the evaluation for as long as it runs.
+func (r *Propl[T]) WithPolicyTimeout(d time.Duration) *Propl[T] {
+	r.policyTimeout = d
+	return r
+}
+
+// WithEvaluationMode sets how much of the policy set is evaluated once infractions
+// are found. Defaults to CollectAll.
+func (r *Propl[T]) WithEvaluationMode(mode EvaluationMode) *Propl[T] {
+	r.mode = mode
+	return r
+}
+
+// WithMaxInfractions stops evaluating once n infractions have been found. n must be
+// at least 1, otherwise Err reports it until a valid maximum is set.
+//
+// With WithConcurrency, no policy is started once the limit is reached, but policies
+// that have already started, including custom evals, run to completion. At most n
+// infractions are reported either way. The same goes for FailFast.
+func (r *Propl[T]) WithMaxInfractions(n int) *Propl[T] {
+	r.mode = MaxInfractions
+	r.maxInfractions = n
+	return r
+}
+
+// WithDuplicatePolicyDetection reports policies declared more than once on the same path
+// with the same traits, conditions, severity, code and message. Custom evals and
+// policies with When guards are never considered duplicates since functions can't be
+// compared.
+func (r *Propl[T]) WithDuplicatePolicyDetection() *Propl[T] {
+	r.detectDuplicates = true
+	return r
+}
+
+// Err returns an error describing any problems with the declared policies, or nil.
+// Evaluate returns this error without evaluating any policies.
+func (r *Propl[T]) Err() error {
+	errs := append([]error(nil), r.errs...)
+	if r.mode == MaxInfractions && r.maxInfractions < 1 {
+		errs = append(errs, fmt.Errorf("invalid maximum of %d infractions, set at least 1 with WithMaxInfractions", r.maxInfractions))
+	}
+	if !r.detectDuplicates {
+		return errors.Join(errs...)
+	}
+	seen := make(map[string]struct{})
+	for _, dp := range r.policies {
+		// When predicates are functions, which can't be compared
+		if dp.identity == "" || len(dp.options.guards) > 0 {
+			continue
+		}
+		key := dp.path + " " + dp.identity + " " + dp.options.identity()
+		if _, ok := seen[key]; ok {
+			errs = append(errs, fmt.Errorf("duplicate policy declared on %s", dp.path))
+			continue
+		}
+		seen[key] = struct{}{}
 	}
+	return errors.Join(errs...)
+}
+
+// FieldPolicy validates that the field at the provided path has the traits
+// under the provided conditions, e.g. to compare two fields:
+//
+//	FieldPolicy("event.start_time", LessThanField("event.end_time"), InMessage)
+func (r *Propl[T]) FieldPolicy(path string, traits Trait, conditions Condition, opts ...PolicyOption) *Propl[T] {
+	traits = r.resolveResourceNames(path, traits)
+	dp := r.addPolicy(path, traitRule(traits), policyIdentity(conditions, traits), func(store *fieldStore[T]) Policy {
+		return &policy{
+			subject:    store.loadFieldsFromPath(path).getByPath(path),
+			conditions: conditions,
+			traits:     bindTraits(store, path, traits),
+		}
+	}, opts)
+	dp.traits, dp.conditions = traits, conditions
 	return r
 }
 
 // NeverZero validates that the field at the provided path
 // is always (in body or mask) non-zero
-func (r *Propl[T]) NeverZero(path string) *Propl[T] {
-	fp := &policy{
-		subject:    r.fieldStore.loadFieldsFromPath(path).getByPath(path),
-		conditions: InMask.And(InMessage),
-		traits: &trait{
-			traitType: NotZero,
-		},
-	}
-	r.policies[path] = fp
-	return r
+func (r *Propl[T]) NeverZero(path string, opts ...PolicyOption) *Propl[T] {
+	return r.FieldPolicy(path, &trait{traitType: NotZero}, InMask.And(InMessage), opts...)
 }
 
 // NeverZeroWhen validates that the field at the provided location is
 // not zero under the provided conditions (e.g. in a field mask)
-func (r *Propl[T]) NeverZeroWhen(path string, conditions Condition) *Propl[T] {
-	fp := &policy{
-		subject:    r.fieldStore.loadFieldsFromPath(path).getByPath(path),
-		conditions: conditions,
-		traits: &trait{
-			traitType: NotZero,
-		},
-	}
-	r.policies[path] = fp
-	return r
+func (r *Propl[T]) NeverZeroWhen(path string, conditions Condition, opts ...PolicyOption) *Propl[T] {
+	return r.FieldPolicy(path, &trait{traitType: NotZero}, conditions, opts...)
+}
+
+// ResourceName validates that the field at the provided path is set to a resource
+// name matching the patterns from its google.api.resource_reference or
+// google.api.resource annotation. Use FieldPolicy with the ResourceName trait to
+// declare the patterns explicitly.
+func (r *Propl[T]) ResourceName(path string, opts ...PolicyOption) *Propl[T] {
+	return r.FieldPolicy(path, ResourceName(), InMessage, opts...)
 }
 
 // CustomEval asserts the field is always present and set before running
 // a user-provided function that receives the entire message as an arg
-func (r *Propl[T]) CustomEval(path string, c func(t T) error) *Propl[T] {
-	fp := &customPolicy[T]{
-		conditions: InMask.And(InMessage),
-		arg:        r.fieldStore.message(),
-		subject:    r.fieldStore.loadFieldsFromPath(path).getByPath(path),
-		f:          c,
-	}
-	r.policies[path] = fp
-	return r
+func (r *Propl[T]) CustomEval(path string, c func(t T) error, opts ...PolicyOption) *Propl[T] {
+	return r.CustomEvalContext(path, ignoreContext(c), opts...)
+}
+
+// CustomEvalContext is CustomEval for functions that need the evaluation context,
+// e.g. to honor cancellation while calling out to a cache or database.
+func (r *Propl[T]) CustomEvalContext(path string, c func(ctx context.Context, t T) error, opts ...PolicyOption) *Propl[T] {
+	return r.CustomEvalContextWhen(path, InMask.And(InMessage), c, opts...)
 }
 
 // CustomEvalWhen runs a custom eval function that receives the entire message as an arg
 // when the field at the specified location meets the specified conditions
-func (r *Propl[T]) CustomEvalWhen(path string, conditions Condition, c func(t T) error) *Propl[T] {
-	fp := &customPolicy[T]{
-		conditions: conditions,
-		arg:        r.fieldStore.message(),
-		subject:    r.fieldStore.loadFieldsFromPath(path).getByPath(path),
-		f:          c,
-	}
-	r.policies[path] = fp
+func (r *Propl[T]) CustomEvalWhen(path string, conditions Condition, c func(t T) error, opts ...PolicyOption) *Propl[T] {
+	return r.CustomEvalContextWhen(path, conditions, ignoreContext(c), opts...)
+}
+
+// CustomEvalContextWhen is CustomEvalWhen for functions that need the evaluation context.
+func (r *Propl[T]) CustomEvalContextWhen(path string, conditions Condition, c func(ctx context.Context, t T) error, opts ...PolicyOption) *Propl[T] {
+	r.addPolicy(path, "CustomEval", "", func(store *fieldStore[T]) Policy {
+		return &customPolicy[T]{
+			conditions: conditions,
+			arg:        store.message(),
+			subject:    store.loadFieldsFromPath(path).getByPath(path),
+			f:          c,
+		}
+	}, opts).conditions = conditions
+	return r
+}
+
+// Immutable validates that the field at the provided path is not changed by an update.
+// When the field is in the mask, its value must equal the stored resource's. The
+// resource returned by load may be the message itself or the message type of any
+// field along the path, e.g. a User for "user.email" in an UpdateUserRequest.
+func (r *Propl[T]) Immutable(path string, load ResourceLoader, opts ...PolicyOption) *Propl[T] {
+	return r.immutable(path, load, false, opts)
+}
+
+// WriteOnce is Immutable, except that the field may be changed while the stored
+// resource's value is zero, i.e. it can be set once.
+func (r *Propl[T]) WriteOnce(path string, load ResourceLoader, opts ...PolicyOption) *Propl[T] {
+	return r.immutable(path, load, true, opts)
+}
+
+func (r *Propl[T]) immutable(path string, load ResourceLoader, once bool, opts []PolicyOption) *Propl[T] {
+	rule, message := "Immutable", MessageImmutable
+	if once {
+		rule, message = "WriteOnce", MessageWriteOnce
+	}
+	r.addPolicy(path, rule, "", func(store *fieldStore[T]) Policy {
+		return &immutablePolicy{
+			subject: store.loadFieldsFromPath(path).getByPath(path),
+			path:    path,
+			request: store.message().ProtoReflect().Descriptor(),
+			load:    load,
+			once:    once,
+		}
+	}, opts).infractions = []*RuleError{newRuleError(message)}
+	return r
+}
+
+// AtLeastOneOf validates that at least one of the fields at the provided paths
+// is set. Infractions are keyed by the group rather than by a single path.
+func (r *Propl[T]) AtLeastOneOf(paths []string, opts ...PolicyOption) *Propl[T] {
+	return r.groupPolicy(atLeastOneOf, paths, InMessage, opts)
+}
+
+// AtLeastOneOfWhen is AtLeastOneOf under the provided conditions. With InMask, only
+// the fields in the mask are considered.
+func (r *Propl[T]) AtLeastOneOfWhen(paths []string, conditions Condition, opts ...PolicyOption) *Propl[T] {
+	return r.groupPolicy(atLeastOneOf, paths, conditions, opts)
+}
+
+// MutuallyExclusive validates that at most one of the fields at the provided paths
+// is set.
+func (r *Propl[T]) MutuallyExclusive(paths []string, opts ...PolicyOption) *Propl[T] {
+	return r.groupPolicy(mutuallyExclusive, paths, InMessage, opts)
+}
+
+// MutuallyExclusiveWhen is MutuallyExclusive under the provided conditions.
+func (r *Propl[T]) MutuallyExclusiveWhen(paths []string, conditions Condition, opts ...PolicyOption) *Propl[T] {
+	return r.groupPolicy(mutuallyExclusive, paths, conditions, opts)
+}
+
+// AllOrNone validates that either every field at the provided paths is set
+// or none of them are.
+func (r *Propl[T]) AllOrNone(paths []string, opts ...PolicyOption) *Propl[T] {
+	return r.groupPolicy(allOrNone, paths, InMessage, opts)
+}
+
+// AllOrNoneWhen is AllOrNone under the provided conditions.
+func (r *Propl[T]) AllOrNoneWhen(paths []string, conditions Condition, opts ...PolicyOption) *Propl[T] {
+	return r.groupPolicy(allOrNone, paths, conditions, opts)
+}
+
+// ExactlyOneOf validates that exactly one of the fields at the provided paths is set.
+func (r *Propl[T]) ExactlyOneOf(paths []string, opts ...PolicyOption) *Propl[T] {
+	return r.groupPolicy(exactlyOneOf, paths, InMessage, opts)
+}
+
+// ExactlyOneOfWhen is ExactlyOneOf under the provided conditions.
+func (r *Propl[T]) ExactlyOneOfWhen(paths []string, conditions Condition, opts ...PolicyOption) *Propl[T] {
+	return r.groupPolicy(exactlyOneOf, paths, conditions, opts)
+}
+
+func (r *Propl[T]) groupPolicy(kind groupKind, paths []string, conditions Condition, opts []PolicyOption) *Propl[T] {
+	key := groupKey(kind, paths)
+	dp := r.addPolicy(key, kind.String(), fmt.Sprintf("%d %s", conditions, key), func(store *fieldStore[T]) Policy {
+		gp := &groupPolicy{
+			kind:       kind,
+			paths:      paths,
+			conditions: conditions,
+		}
+		for _, p := range paths {
+			gp.subjects = append(gp.subjects, store.loadFieldsFromPath(p).getByPath(p))
+		}
+		return gp
+	}, opts)
+	dp.paths, dp.conditions = paths, conditions
+	dp.infractions = []*RuleError{newRuleError(kind.message(), "paths", strings.Join(paths, ", "))}
 	return r
 }
 
@@ -108,29 +357,267 @@ func (r *Propl[T]) E(ctx context.Context) error {
 // each infraction. If a precheck is specified and returns an error, this exits
 // and field policies are not evaluated.
 //
+// Policies are evaluated in the order they were declared, and infractions are
+// reported in that same order regardless of how many are evaluated at a time.
+// A path with more than one infraction maps to their joined errors when using
+// a FieldInfractionsHandler.
+// Depending on the evaluation mode, evaluation may stop before every policy
+// has been evaluated.
+//
+// Only infractions with SeverityError are passed to the infractions handler. Use
+// WithWarningsHandler or Check to see the rest.
+//
+// If ctx is done before every policy has been evaluated, the context's error is
+// returned instead of the infractions.
+//
 // To use your own infractionsHandler, specify a handler using WithInfractionsHandler.
 func (r *Propl[T]) Evaluate(ctx context.Context) error {
-	if r.precheck != nil {
-		if err := r.precheck(ctx, r.fieldStore.message()); err != nil {
-			return err
-		}
+	res, err := r.Check(ctx)
+	if err != nil {
+		return err
+	}
+	if len(res.Infractions) == 0 {
+		return nil
 	}
 	// ensure some handler is set
 	r.ensureFieldInfractionsHandler()
-	finfractions := make(map[string]error)
-	for id, p := range r.policies {
-		if err := p.Execute(); err != nil {
-			finfractions[id] = err
+	if r.fieldInfractionsHandler != nil {
+		return r.fieldInfractionsHandler(res.Infractions.Map())
+	}
+	return r.orderedHandler(res.Infractions)
+}
+
+// Check evaluates the declared policies like Evaluate, but returns the infractions
+// in a Result instead of passing them to the infractions handler. The error is
+// non-nil only when evaluation could not complete, i.e. the policies are invalid,
+// the precheck failed or ctx is done.
+func (r *Propl[T]) Check(ctx context.Context) (*Result, error) {
+	if err := r.errNoMessage(); err != nil {
+		return nil, err
+	}
+	return r.check(ctx, r.msg, r.maskPaths, false, nil)
+}
+
+// CheckMessage evaluates the declared policies against msg instead of the message the
+// policy aggregate was created for, with maskPaths as the mask. msg must be a T of the
+// same message type, which matters when T is dynamic, e.g. a *dynamicpb.Message.
+func (r *Propl[T]) CheckMessage(ctx context.Context, msg proto.Message, maskPaths ...string) (*Result, error) {
+	t, err := r.assertMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+	return r.check(ctx, t, maskPaths, false, nil)
+}
+
+// assertMessage returns msg as a T if it's the message the policies were declared for.
+func (r *Propl[T]) assertMessage(msg proto.Message) (T, error) {
+	t, ok := msg.(T)
+	if !ok {
+		return t, fmt.Errorf("policies for %T cannot evaluate %T", r.msg, msg)
+	}
+	// policies declared for a nil message when T is an interface evaluate any message
+	if desc, err := r.descriptor(); err == nil && msg.ProtoReflect().Descriptor().FullName() != desc.FullName() {
+		return t, fmt.Errorf("policies for %s cannot evaluate %s", desc.FullName(), msg.ProtoReflect().Descriptor().FullName())
+	}
+	return t, nil
+}
+
+// CheckReflect is CheckMessage for a protoreflect.Message, e.g. a dynamicpb message.
+func (r *Propl[T]) CheckReflect(ctx context.Context, msg protoreflect.Message, maskPaths ...string) (*Result, error) {
+	return r.CheckMessage(ctx, msg.Interface(), maskPaths...)
+}
+
+// check evaluates the policies against msg. If prepared, the defaults and normalizers
+// were already applied to it. If explanation is not nil, the trace of each policy is
+// added to it.
+func (r *Propl[T]) check(ctx context.Context, msg T, maskPaths []string, prepared bool, explanation *Explanation) (res *Result, err error) {
+	if len(r.hooks) > 0 && !isDryRun(ctx) {
+		start := time.Now()
+		ctx = r.evaluateStart(ctx, msg)
+		defer func() {
+			r.evaluateDone(ctx, msg, start, res, err)
+		}()
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	if !prepared {
+		r.prepare(ctx, msg, maskPaths)
+	}
+	if r.precheck != nil {
+		if err := r.precheck(ctx, msg); err != nil {
+			return nil, err
+		}
+	}
+	ctx = withEvaluationCache(ctx)
+	bound, store := r.bind(msg, maskPaths)
+	var tracers []*policyTracer
+	if explanation != nil {
+		tracers = tracePolicies(r, bound, store)
+	}
+	errs := make([]error, len(bound))
+	if r.concurrency > 1 {
+		r.executeConcurrently(ctx, bound, errs)
+	} else {
+		r.executeSerially(ctx, bound, errs)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	res = &Result{}
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		var nested *nestedInfractions
+		if errors.As(err, &nested) {
+			desc := store.message().ProtoReflect().Descriptor()
+			infractions := r.redactInfractions(desc, nested.result.Infractions)
+			warnings := r.redactInfractions(desc, nested.result.Warnings)
+			r.localizeInfractions(ctx, infractions)
+			r.localizeInfractions(ctx, warnings)
+			res.Infractions = append(res.Infractions, infractions...)
+			res.Warnings = append(res.Warnings, warnings...)
+			continue
+		}
+		bp := bound[i]
+		err = r.infractionError(ctx, bp, err)
+		errs[i] = err
+		fi := FieldInfraction{
+			Path:     bp.path,
+			Paths:    bp.paths,
+			Err:      err,
+			Severity: bp.options.severity,
+			Value:    r.infractionValue(store, bp),
+			Code:     bp.options.code,
+		}
+		if fi.Severity == SeverityError {
+			res.Infractions = append(res.Infractions, fi)
+		} else {
+			res.Warnings = append(res.Warnings, fi)
 		}
 	}
-	if len(finfractions) > 0 {
-		return r.fieldInfractionsHandler(finfractions)
+	if explanation != nil {
+		finishTraces(explanation, tracers, bound, errs)
+	}
+	if limit := r.infractionLimit(); limit > 0 && len(res.Infractions) > limit {
+		res.Infractions = res.Infractions[:limit]
+	}
+	if len(res.Warnings) > 0 && r.warningsHandler != nil && !isDryRun(ctx) {
+		r.warningsHandler(ctx, res.Warnings)
+	}
+	return res, nil
+}
+
+// addPolicy declares a policy on path. A path may have any number of policies,
+// all of which are evaluated. bind creates the policy for the message being
+// evaluated, rule names the kind of policy, and identity describes it for
+// duplicate detection.
+func (r *Propl[T]) addPolicy(path, rule, identity string, bind func(store *fieldStore[T]) Policy, opts []PolicyOption) *declaredPolicy[T] {
+	dp := &declaredPolicy[T]{
+		path:     path,
+		rule:     rule,
+		identity: identity,
+		bind:     bind,
+		options:  newPolicyOptions(opts),
+	}
+	r.policies = append(r.policies, dp)
+	return dp
+}
+
+// bind creates the declared policies for msg. Each evaluation binds the policies
+// to a new field store, so the policies can be evaluated against any message of
+// their type, and field values are read when evaluation starts.
+func (r *Propl[T]) bind(msg T, maskPaths []string) ([]*boundPolicy, *fieldStore[T]) {
+	store := newFieldStore(msg, maskPaths...)
+	name := msg.ProtoReflect().Descriptor().FullName()
+	bound := make([]*boundPolicy, len(r.policies))
+	for i, dp := range r.policies {
+		bp := &boundPolicy{
+			path:    dp.path,
+			paths:   dp.paths,
+			options: dp.options,
+			policy:  dp.bind(store),
+			info: PolicyInfo{
+				Message:  name,
+				Path:     dp.path,
+				Paths:    dp.paths,
+				Rule:     dp.rule,
+				Code:     dp.options.code,
+				Severity: dp.options.severity,
+			},
+		}
+		for _, g := range dp.options.guards {
+			bp.guards = append(bp.guards, store.loadFieldsFromPath(g.path).getByPath(g.path))
+		}
+		bound[i] = bp
 	}
-	return nil
+	return bound, store
 }
 
 func (r *Propl[T]) ensureFieldInfractionsHandler() {
-	if r.fieldInfractionsHandler == nil {
-		r.fieldInfractionsHandler = defaultFieldInfractionsHandler
+	if r.fieldInfractionsHandler == nil && r.orderedHandler == nil {
+		r.orderedHandler = defaultFieldInfractionsHandler
+	}
+}
+
+// PolicySet is a set of policies that can be evaluated against any message of
+// its type. A *Propl is a PolicySet.
+type PolicySet interface {
+	CheckMessage(ctx context.Context, msg proto.Message, maskPaths ...string) (*Result, error)
+}
+
+var _ PolicySet = (*Propl[proto.Message])(nil)
+
+// declaredPolicy is a policy, the path it was declared on and the options
+// it was declared with. Message-level policies are declared on a group key,
+// and paths lists the fields in the group.
+type declaredPolicy[T proto.Message] struct {
+	path     string
+	paths    []string
+	rule     string
+	identity string
+	bind     func(store *fieldStore[T]) Policy
+	options  *policyOptions
+	// traits, conditions and infractions describe the policy for Describe.
+	// infractions are the errors the policy reports other than those of its
+	// traits and conditions.
+	traits      Trait
+	conditions  Condition
+	infractions []*RuleError
+}
+
+// boundPolicy is a declared policy bound to the message being evaluated.
+// guards holds the field of each of the options' When guards.
+type boundPolicy struct {
+	path    string
+	paths   []string
+	options *policyOptions
+	policy  Policy
+	guards  []*fieldData
+	info    PolicyInfo
+}
+
+// guardsHold reports whether every When guard's predicate holds.
+func (bp *boundPolicy) guardsHold() bool {
+	for i, g := range bp.options.guards {
+		f := bp.guards[i]
+		if f == nil {
+			if !g.predicate(protoreflect.Value{}, false) {
+				return false
+			}
+			continue
+		}
+		if !g.predicate(f.fv(), f.s()) {
+			return false
+		}
+	}
+	return true
+}
+
+// ignoreContext adapts a custom eval function that does not take a context.
+func ignoreContext[T proto.Message](c func(t T) error) func(ctx context.Context, t T) error {
+	return func(_ context.Context, t T) error {
+		return c(t)
 	}
 }
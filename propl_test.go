@@ -1,14 +1,27 @@
 package propl
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	proplv1 "buf.build/gen/go/signal426/propl/protocolbuffers/go/propl/v1"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestFieldPolicies(t *testing.T) {
@@ -26,6 +39,20 @@ func TestFieldPolicies(t *testing.T) {
 		assert.Error(t, err)
 	})
 
+	t.Run("it should pass non-zero on a set field", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				FirstName: "Bob",
+			},
+		}
+		p := For(req).NeverZero("user.first_name")
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.NoError(t, err)
+	})
+
 	t.Run("it should validate not eq", func(t *testing.T) {
 		// arrange
 		req := &proplv1.CreateUserRequest{
@@ -146,3 +173,1986 @@ func TestFieldPolicies(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestConcurrentEvaluation(t *testing.T) {
+	t.Run("it should evaluate policies concurrently up to the limit", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				Id:        "abc123",
+				FirstName: "bob",
+				LastName:  "loblaw",
+			},
+		}
+		var running, maxRunning int32
+		slowEval := func(ctx context.Context, _ *proplv1.CreateUserRequest) error {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			return errors.New("taken")
+		}
+		p := For(req).
+			WithConcurrency(2).
+			CustomEvalContext("user.id", slowEval).
+			CustomEvalContext("user.first_name", slowEval).
+			CustomEvalContext("user.last_name", slowEval).
+			WithFieldInfractionsHandler(func(errs map[string]error) error {
+				assert.Len(t, errs, 3)
+				return errors.New("infractions")
+			})
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.Error(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&maxRunning))
+	})
+
+	t.Run("it should report a policy that exceeds its timeout", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				Id: "abc123",
+			},
+		}
+		var infractions map[string]error
+		p := For(req).
+			WithPolicyTimeout(10*time.Millisecond).
+			CustomEvalContext("user.id", func(ctx context.Context, _ *proplv1.CreateUserRequest) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}).
+			WithFieldInfractionsHandler(func(errs map[string]error) error {
+				infractions = errs
+				return errors.New("infractions")
+			})
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.Error(t, err)
+		assert.ErrorIs(t, infractions["user.id"], context.DeadlineExceeded)
+	})
+
+	t.Run("it should wait for a policy that ignores its timeout", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				Id: "abc123",
+			},
+		}
+		var returned bool
+		p := For(req).
+			WithPolicyTimeout(time.Millisecond).
+			CustomEval("user.id", func(_ *proplv1.CreateUserRequest) error {
+				time.Sleep(10 * time.Millisecond)
+				returned = true
+				return nil
+			})
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, returned)
+		assert.ErrorIs(t, res.Infractions[0].Err, context.DeadlineExceeded)
+	})
+
+	t.Run("it should stop evaluating when the context is done", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				Id: "abc123",
+			},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		p := For(req).
+			WithConcurrency(4).
+			CustomEvalContext("user.id", func(ctx context.Context, _ *proplv1.CreateUserRequest) error {
+				cancel()
+				<-ctx.Done()
+				return ctx.Err()
+			})
+		// act
+		err := p.E(ctx)
+		// assert
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestEvaluationOrder(t *testing.T) {
+	t.Run("it should report infractions in declaration order", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{},
+		}
+		var paths []string
+		p := For(req).
+			NeverZero("user.last_name").
+			NeverZero("user.id").
+			NeverZero("user.first_name").
+			NeverZero("user.primary_address").
+			WithConcurrency(3).
+			WithOrderedFieldInfractionsHandler(func(infractions FieldInfractions) error {
+				for _, i := range infractions {
+					paths = append(paths, i.Path)
+				}
+				return errors.New("infractions")
+			})
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.Error(t, err)
+		assert.Equal(t, []string{"user.last_name", "user.id", "user.first_name", "user.primary_address"}, paths)
+	})
+
+	t.Run("it should produce the same error text on every evaluation", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{},
+		}
+		p := For(req).
+			NeverZero("user.last_name").
+			NeverZero("user.id").
+			NeverZero("user.first_name")
+		// act
+		first := p.E(context.Background())
+		// assert
+		assert.Error(t, first)
+		for i := 0; i < 10; i++ {
+			assert.EqualError(t, p.E(context.Background()), first.Error())
+		}
+	})
+}
+
+func TestMultiplePoliciesPerPath(t *testing.T) {
+	t.Run("it should evaluate every policy on a path", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				Id:        "xyz",
+				FirstName: "bob",
+			},
+		}
+		var infractions FieldInfractions
+		p := For(req).
+			NeverZero("user.id").
+			CustomEval("user.id", func(t *proplv1.CreateUserRequest) error {
+				return errors.New("must be abc123")
+			}).
+			CustomEval("user.id", func(t *proplv1.CreateUserRequest) error {
+				return errors.New("must be 6 characters")
+			}).
+			CustomEval("user.first_name", func(t *proplv1.CreateUserRequest) error {
+				return errors.New("cant be bob")
+			}).
+			WithOrderedFieldInfractionsHandler(func(i FieldInfractions) error {
+				infractions = i
+				return errors.New("infractions")
+			})
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.Error(t, err)
+		assert.Equal(t, []string{"user.id", "user.first_name"}, infractions.Paths())
+		assert.Len(t, infractions.ByPath()["user.id"], 2)
+		assert.ErrorContains(t, infractions.Map()["user.id"], "must be abc123")
+	})
+
+	t.Run("it should report duplicate declarations when detection is on", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				Id: "abc123",
+			},
+		}
+		p := For(req).
+			WithDuplicatePolicyDetection().
+			NeverZero("user.id").
+			NeverZeroWhen("user.id", InMask).
+			NeverZero("user.id")
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.EqualError(t, err, "duplicate policy declared on user.id")
+	})
+
+	t.Run("it should allow duplicate declarations when detection is off", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				Id: "abc123",
+			},
+		}
+		p := For(req).
+			NeverZero("user.id").
+			NeverZero("user.id")
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should not report declarations that differ in their options", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				Id:        "abc123",
+				FirstName: "bob",
+				LastName:  "loblaw",
+			},
+		}
+		p := For(req).
+			WithDuplicatePolicyDetection().
+			NeverZero("user.first_name", When("user.id", IsSet())).
+			NeverZero("user.first_name", When("user.last_name", IsSet())).
+			NeverZero("user.last_name").
+			NeverZero("user.last_name", WithSeverity(SeverityWarning)).
+			NeverZero("user.last_name", WithCode("LAST_NAME_REQUIRED")).
+			NeverZero("user.last_name", WithMessage("a last name is required"))
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.NoError(t, err)
+	})
+}
+
+func TestEvaluationModes(t *testing.T) {
+	newRequest := func() *proplv1.CreateUserRequest {
+		return &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				FirstName: "bob",
+			},
+		}
+	}
+	failing := func(msg string, calls *int32) func(t *proplv1.CreateUserRequest) error {
+		return func(t *proplv1.CreateUserRequest) error {
+			atomic.AddInt32(calls, 1)
+			return errors.New(msg)
+		}
+	}
+
+	for _, concurrency := range []int{1, 4} {
+		t.Run(fmt.Sprintf("it should stop at the first infraction with concurrency %d", concurrency), func(t *testing.T) {
+			// arrange
+			var calls int32
+			var infractions FieldInfractions
+			p := For(newRequest()).
+				WithConcurrency(concurrency).
+				WithEvaluationMode(FailFast).
+				NeverZero("user.id").
+				CustomEval("user.first_name", failing("cant be bob", &calls)).
+				WithOrderedFieldInfractionsHandler(func(i FieldInfractions) error {
+					infractions = i
+					return errors.New("infractions")
+				})
+			// act
+			err := p.E(context.Background())
+			// assert
+			assert.Error(t, err)
+			assert.Equal(t, []string{"user.id"}, infractions.Paths())
+			if concurrency == 1 {
+				assert.Equal(t, int32(0), calls)
+			}
+		})
+
+		t.Run(fmt.Sprintf("it should skip the rest of a path after an infraction with concurrency %d", concurrency), func(t *testing.T) {
+			// arrange
+			var calls int32
+			var infractions FieldInfractions
+			p := For(newRequest()).
+				WithConcurrency(concurrency).
+				WithEvaluationMode(StopPerPath).
+				CustomEval("user.first_name", failing("cant be bob", &calls)).
+				NeverZero("user.id").
+				CustomEval("user.first_name", failing("must be capitalized", &calls)).
+				WithOrderedFieldInfractionsHandler(func(i FieldInfractions) error {
+					infractions = i
+					return errors.New("infractions")
+				})
+			// act
+			err := p.E(context.Background())
+			// assert
+			assert.Error(t, err)
+			assert.Len(t, infractions, 2)
+			assert.Equal(t, []string{"user.first_name", "user.id"}, infractions.Paths())
+			assert.Equal(t, int32(1), calls)
+		})
+	}
+
+	t.Run("it should stop after the max infractions", func(t *testing.T) {
+		// arrange
+		var calls int32
+		var infractions FieldInfractions
+		p := For(newRequest()).
+			WithMaxInfractions(2).
+			NeverZero("user.id").
+			NeverZero("user.last_name").
+			CustomEval("user.first_name", failing("cant be bob", &calls)).
+			WithOrderedFieldInfractionsHandler(func(i FieldInfractions) error {
+				infractions = i
+				return errors.New("infractions")
+			})
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.Error(t, err)
+		assert.Equal(t, []string{"user.id", "user.last_name"}, infractions.Paths())
+		assert.Equal(t, int32(0), calls)
+	})
+
+	t.Run("it should report a max infractions below one", func(t *testing.T) {
+		// arrange
+		p := For(newRequest()).
+			WithMaxInfractions(0).
+			NeverZero("user.id")
+		unset := For(newRequest()).
+			WithEvaluationMode(MaxInfractions).
+			NeverZero("user.id")
+		corrected := For(newRequest()).
+			WithMaxInfractions(0).
+			WithMaxInfractions(3).
+			NeverZero("user.first_name")
+		// act
+		err := p.E(context.Background())
+		unsetErr := unset.Err()
+		correctedErr := corrected.E(context.Background())
+		// assert
+		assert.EqualError(t, err, "invalid maximum of 0 infractions, set at least 1 with WithMaxInfractions")
+		assert.Error(t, unsetErr)
+		assert.NoError(t, correctedErr)
+	})
+
+	t.Run("it should finish started policies when evaluating concurrently", func(t *testing.T) {
+		// arrange
+		var calls int32
+		started := make(chan struct{})
+		p := For(newRequest()).
+			WithConcurrency(2).
+			WithMaxInfractions(1).
+			CustomEval("user.first_name", func(_ *proplv1.CreateUserRequest) error {
+				// started before the other policy can reach the limit
+				<-started
+				atomic.AddInt32(&calls, 1)
+				return errors.New("taken")
+			}).
+			CustomEval("user.first_name", func(_ *proplv1.CreateUserRequest) error {
+				close(started)
+				atomic.AddInt32(&calls, 1)
+				return errors.New("cant be bob")
+			}).
+			CustomEval("user.first_name", failing("must be capitalized", &calls))
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Len(t, res.Infractions, 1)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestSeverity(t *testing.T) {
+	t.Run("it should not fail evaluation on warnings", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				Id:        "abc123",
+				FirstName: "bob",
+			},
+		}
+		var warnings FieldInfractions
+		p := For(req).
+			NeverZero("user.id").
+			NeverZero("user.last_name", WithSeverity(SeverityWarning)).
+			CustomEval("user.first_name", func(t *proplv1.CreateUserRequest) error {
+				return errors.New("cant be bob")
+			}, WithSeverity(SeverityInfo)).
+			WithWarningsHandler(func(_ context.Context, w FieldInfractions) {
+				warnings = w
+			})
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user.last_name", "user.first_name"}, warnings.Paths())
+		assert.Equal(t, SeverityWarning, warnings[0].Severity)
+		assert.Equal(t, SeverityInfo, warnings[1].Severity)
+	})
+
+	t.Run("it should separate errors from warnings in the result", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				FirstName: "bob",
+			},
+		}
+		p := For(req).
+			WithEvaluationMode(FailFast).
+			NeverZero("user.last_name", WithSeverity(SeverityWarning)).
+			NeverZero("user.id")
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, res.Failed())
+		assert.Equal(t, []string{"user.id"}, res.Infractions.Paths())
+		assert.Equal(t, []string{"user.last_name"}, res.Warnings.Paths())
+	})
+}
+
+func TestGroupPolicies(t *testing.T) {
+	t.Run("it should validate at least one of", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				Id: "abc123",
+			},
+		}
+		p := For(req).
+			AtLeastOneOf([]string{"user.first_name", "user.last_name"})
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Len(t, res.Infractions, 1)
+		assert.Equal(t, "AtLeastOneOf(user.first_name, user.last_name)", res.Infractions[0].Path)
+		assert.Equal(t, []string{"user.first_name", "user.last_name"}, res.Infractions[0].Paths)
+	})
+
+	t.Run("it should validate mutually exclusive and exactly one of", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				FirstName: "bob",
+				LastName:  "loblaw",
+			},
+		}
+		p := For(req).
+			MutuallyExclusive([]string{"user.first_name", "user.last_name"}).
+			ExactlyOneOf([]string{"user.id", "user.first_name"}).
+			ExactlyOneOf([]string{"user.id", "user.last_name", "user.first_name"})
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"MutuallyExclusive(user.first_name, user.last_name)",
+			"ExactlyOneOf(user.id, user.last_name, user.first_name)",
+		}, res.Infractions.Paths())
+	})
+
+	t.Run("it should validate all or none", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				PrimaryAddress: &proplv1.Address{
+					Line1: "a",
+				},
+			},
+		}
+		p := For(req).
+			AllOrNone([]string{"user.first_name", "user.last_name"}).
+			AllOrNone([]string{"user.primary_address.line1", "user.primary_address.line2"})
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"AllOrNone(user.primary_address.line1, user.primary_address.line2)"}, res.Infractions.Paths())
+	})
+
+	t.Run("it should only consider masked fields when in mask", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{
+				FirstName: "bob",
+				LastName:  "loblaw",
+			},
+			UpdateMask: &fieldmaskpb.FieldMask{
+				Paths: []string{"first_name"},
+			},
+		}
+		p := For(req, req.GetUpdateMask().GetPaths()...).
+			MutuallyExclusiveWhen([]string{"user.first_name", "user.last_name"}, InMask).
+			AtLeastOneOfWhen([]string{"user.id", "user.primary_address"}, InMask)
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.NoError(t, err)
+	})
+}
+
+func TestWhen(t *testing.T) {
+	t.Run("it should only evaluate when the other field matches", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				Id:        "abc123",
+				FirstName: "bob",
+			},
+		}
+		p := For(req).
+			NeverZero("user.last_name", When("user.first_name", Equals("bob"))).
+			NeverZero("user.primary_address", When("user.first_name", Equals("alice")))
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user.last_name"}, res.Infractions.Paths())
+	})
+
+	t.Run("it should evaluate based on the presence of the other field", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				PrimaryAddress: &proplv1.Address{
+					Line1: "a",
+				},
+			},
+		}
+		p := For(req).
+			NeverZero("user.primary_address.line2", When("user.primary_address.line1", IsSet())).
+			NeverZero("user.first_name", When("user.last_name", IsSet())).
+			NeverZero("user.id", When("user.last_name", IsUnset()), When("user.first_name", NotEquals("bob")))
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user.primary_address.line2", "user.id"}, res.Infractions.Paths())
+	})
+}
+
+func TestFieldComparisons(t *testing.T) {
+	t.Run("it should compare fields", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				Id:        "abc123",
+				FirstName: "bob",
+				LastName:  "loblaw",
+				PrimaryAddress: &proplv1.Address{
+					Line1: "a",
+					Line2: "b",
+				},
+			},
+		}
+		p := For(req).
+			FieldPolicy("user.first_name", LessThanField("user.last_name"), InMessage).
+			FieldPolicy("user.last_name", GreaterThanField("user.first_name"), InMessage).
+			FieldPolicy("user.id", NotEqualsField("user.first_name"), InMessage).
+			FieldPolicy("user.primary_address.line1", EqualsField("user.primary_address.line2"), InMessage)
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Len(t, res.Infractions, 1)
+		assert.EqualError(t, res.Infractions[0].Err, "user.primary_address.line1 should be equal to user.primary_address.line2")
+	})
+
+	t.Run("it should compare against unset fields", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				Id: "abc123",
+			},
+		}
+		p := For(req).
+			FieldPolicy("user.id", EqualsField("user.first_name"), InMessage).
+			FieldPolicy("user.id", GreaterThanField("user.first_name"), InMessage)
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Len(t, res.Infractions, 1)
+		assert.EqualError(t, res.Infractions[0].Err, "user.id should be equal to user.first_name")
+	})
+}
+
+func TestImmutable(t *testing.T) {
+	stored := &proplv1.User{
+		Id:        "abc123",
+		FirstName: "bob",
+		PrimaryAddress: &proplv1.Address{
+			Line1: "a",
+		},
+	}
+	var loads int32
+	loadUser := LoadOnce(func(ctx context.Context) (proto.Message, error) {
+		atomic.AddInt32(&loads, 1)
+		return stored, nil
+	})
+
+	t.Run("it should reject changes to immutable fields in the mask", func(t *testing.T) {
+		// arrange
+		atomic.StoreInt32(&loads, 0)
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{
+				Id:        "xyz",
+				FirstName: "bob",
+				LastName:  "loblaw",
+				PrimaryAddress: &proplv1.Address{
+					Line1: "b",
+					Line2: "c",
+				},
+			},
+			UpdateMask: &fieldmaskpb.FieldMask{
+				Paths: []string{"id", "first_name", "line1", "line2"},
+			},
+		}
+		p := For(req, req.GetUpdateMask().GetPaths()...).
+			Immutable("user.id", loadUser).
+			Immutable("user.first_name", loadUser).
+			Immutable("user.last_name", loadUser).
+			Immutable("user.primary_address.line1", loadUser).
+			WriteOnce("user.primary_address.line2", loadUser)
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user.id", "user.primary_address.line1"}, res.Infractions.Paths())
+		assert.EqualError(t, res.Infractions[0].Err, "it is immutable and cannot be changed")
+		assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+	})
+
+	t.Run("it should only allow write once fields to be set while empty", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{
+				FirstName: "robert",
+			},
+			UpdateMask: &fieldmaskpb.FieldMask{
+				Paths: []string{"first_name"},
+			},
+		}
+		p := For(req, req.GetUpdateMask().GetPaths()...).
+			WriteOnce("user.first_name", loadUser)
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.EqualError(t, res.Infractions[0].Err, "it has already been set and cannot be changed")
+	})
+
+	t.Run("it should report loader errors on the path", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{
+				Id: "abc123",
+			},
+			UpdateMask: &fieldmaskpb.FieldMask{
+				Paths: []string{"id"},
+			},
+		}
+		p := For(req, req.GetUpdateMask().GetPaths()...).
+			Immutable("user.id", func(ctx context.Context) (proto.Message, error) {
+				return nil, errors.New("not found")
+			})
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.EqualError(t, res.Infractions[0].Err, "unable to load the existing resource: not found")
+	})
+}
+
+func TestValidateMerged(t *testing.T) {
+	loadUser := func(ctx context.Context) (proto.Message, error) {
+		return &proplv1.User{
+			Id:        "abc123",
+			FirstName: "bob",
+			LastName:  "loblaw",
+			PrimaryAddress: &proplv1.Address{
+				Line1: "a",
+			},
+			SecondaryAddresses: []*proplv1.Address{
+				{Line1: "b"},
+			},
+		}, nil
+	}
+	users := For[*proplv1.User](nil).
+		NeverZero("id").
+		NeverZero("primary_address.line1").
+		NeverZero("secondary_addresses").
+		AtLeastOneOf([]string{"first_name", "last_name"})
+
+	t.Run("it should validate the resource produced by the update", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{
+				PrimaryAddress: &proplv1.Address{
+					Line2: "b",
+				},
+			},
+			UpdateMask: &fieldmaskpb.FieldMask{
+				Paths: []string{"first_name", "last_name", "primary_address.line1", "primary_address.line2"},
+			},
+		}
+		p := For(req, req.GetUpdateMask().GetPaths()...).
+			ValidateMerged("user", loadUser, users)
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"user.primary_address.line1",
+			"AtLeastOneOf(user.first_name, user.last_name)",
+		}, res.Infractions.Paths())
+		assert.Equal(t, []string{"user.first_name", "user.last_name"}, res.Infractions[1].Paths)
+	})
+
+	t.Run("it should leave unmasked fields as stored", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{
+				LastName: "loblaw",
+			},
+			UpdateMask: &fieldmaskpb.FieldMask{
+				Paths: []string{"user.last_name"},
+			},
+		}
+		p := For(req, req.GetUpdateMask().GetPaths()...).
+			ValidateMerged("user", loadUser, users)
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should replace repeated fields rather than append to them", func(t *testing.T) {
+		// arrange
+		var merged *proplv1.User
+		captured := For[*proplv1.User](nil).
+			CustomEvalWhen("id", InMessage, func(msg *proplv1.User) error {
+				merged = msg
+				return nil
+			})
+		cleared := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{},
+			UpdateMask: &fieldmaskpb.FieldMask{
+				Paths: []string{"secondary_addresses"},
+			},
+		}
+		replaced := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{
+				SecondaryAddresses: []*proplv1.Address{{Line1: "c"}},
+			},
+		}
+		// act
+		res, err := For(cleared, cleared.GetUpdateMask().GetPaths()...).
+			ValidateMerged("user", loadUser, users).
+			Check(context.Background())
+		replacedErr := For(replaced).
+			ValidateMerged("user", loadUser, captured).
+			E(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user.secondary_addresses"}, res.Infractions.Paths())
+		assert.NoError(t, replacedErr)
+		assert.True(t, proto.Equal(&proplv1.User{
+			Id:                 "abc123",
+			FirstName:          "bob",
+			LastName:           "loblaw",
+			PrimaryAddress:     &proplv1.Address{Line1: "a"},
+			SecondaryAddresses: []*proplv1.Address{{Line1: "c"}},
+		}, merged))
+	})
+
+	t.Run("it should report a resource of another type", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{User: &proplv1.User{}}
+		loadAddress := func(ctx context.Context) (proto.Message, error) {
+			return &proplv1.Address{}, nil
+		}
+		// act
+		res, err := For(req).
+			ValidateMerged("user", loadAddress, users).
+			Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		var re *RuleError
+		assert.ErrorAs(t, res.Infractions[0].Err, &re)
+		assert.Equal(t, MessageMergeType, re.ID)
+		assert.EqualError(t, res.Infractions[0].Err, "cannot apply propl.v1.User to propl.v1.Address")
+	})
+
+	t.Run("it should not evaluate a message of another type", func(t *testing.T) {
+		// act
+		_, err := users.CheckMessage(context.Background(), &proplv1.Address{})
+		// assert
+		assert.Error(t, err)
+	})
+}
+
+func TestNormalize(t *testing.T) {
+	t.Run("it should normalize before evaluating policies", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				Id:        "  ",
+				FirstName: "  Bob   Lob\tLaw ",
+				LastName:  "Café",
+			},
+		}
+		p := For(req).
+			Normalize("user.id", TrimSpace()).
+			Normalize("user.first_name", CollapseWhitespace()).
+			Normalize("user.first_name", ToLower()).
+			Normalize("user.last_name", NFC()).
+			NeverZero("user.id")
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user.id"}, res.Infractions.Paths())
+		assert.Equal(t, "bob lob law", req.GetUser().GetFirstName())
+		assert.Equal(t, "Café", req.GetUser().GetLastName())
+	})
+
+	t.Run("it should only normalize fields in the mask", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{
+				FirstName: " bob ",
+				LastName:  " loblaw ",
+			},
+		}
+		p := For(req, "first_name").
+			Normalize("user.first_name", TrimSpace()).
+			Normalize("user.last_name", TrimSpace())
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "bob", req.GetUser().GetFirstName())
+		assert.Equal(t, " loblaw ", req.GetUser().GetLastName())
+	})
+
+	t.Run("it should clamp numbers", func(t *testing.T) {
+		// arrange
+		low, high, in := wrapperspb.Int32(-5), wrapperspb.UInt64(500), wrapperspb.Double(2.5)
+		// act
+		lowErr := For(low).Normalize("value", Clamp(1, 100)).E(context.Background())
+		highErr := For(high).Normalize("value", Clamp(1, 100)).E(context.Background())
+		inErr := For(in).Normalize("value", Clamp(1, 100)).E(context.Background())
+		// assert
+		assert.NoError(t, errors.Join(lowErr, highErr, inErr))
+		assert.Equal(t, int32(1), low.GetValue())
+		assert.Equal(t, uint64(100), high.GetValue())
+		assert.Equal(t, 2.5, in.GetValue())
+	})
+
+	t.Run("it should limit clamp bounds to the range of the field", func(t *testing.T) {
+		// arrange
+		int32Low, int64High := wrapperspb.Int32(5), wrapperspb.Int64(math.MinInt64)
+		uint32High, uint64Low := wrapperspb.UInt32(math.MaxUint32), wrapperspb.UInt64(5)
+		// act
+		err := errors.Join(
+			For(int32Low).Normalize("value", Clamp(3e9, 4e9)).E(context.Background()),
+			For(int64High).Normalize("value", Clamp(-1e19, -1e19)).E(context.Background()),
+			For(uint32High).Normalize("value", Clamp(-10, -5)).E(context.Background()),
+			For(uint64Low).Normalize("value", Clamp(1e20, 2e20)).E(context.Background()),
+		)
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, int32(math.MaxInt32), int32Low.GetValue())
+		assert.Equal(t, int64(math.MinInt64), int64High.GetValue())
+		assert.Equal(t, uint32(0), uint32High.GetValue())
+		assert.Equal(t, uint64(math.MaxUint64), uint64Low.GetValue())
+	})
+}
+
+func TestDefault(t *testing.T) {
+	address := &proplv1.Address{Line1: "a"}
+
+	t.Run("it should fill unset fields before evaluating policies", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				FirstName: "bob",
+			},
+		}
+		p := For(req).
+			Default("user.first_name", "robert").
+			Default("user.last_name", "loblaw").
+			Default("user.primary_address", address).
+			NeverZero("user.last_name").
+			NeverZero("user.primary_address.line1")
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "bob", req.GetUser().GetFirstName())
+		assert.Equal(t, "loblaw", req.GetUser().GetLastName())
+		assert.True(t, proto.Equal(address, req.GetUser().GetPrimaryAddress()))
+		req.GetUser().GetPrimaryAddress().Line1 = "b"
+		assert.Equal(t, "a", address.GetLine1(), "the default should not be aliased")
+	})
+
+	t.Run("it should not fill fields in the mask when asked", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{},
+		}
+		p := For(req, "last_name").
+			Default("user.first_name", "bob", UnlessInMask()).
+			Default("user.last_name", "loblaw", UnlessInMask())
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "bob", req.GetUser().GetFirstName())
+		assert.Empty(t, req.GetUser().GetLastName())
+	})
+
+	t.Run("it should report defaults that don't match the field", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{}
+		p := For(req).
+			Default("user.first_name", 50).
+			Default("user.secondary_addresses", address).
+			Default("user.nickname", "bob").
+			Default("value", 50)
+		clamped := For(wrapperspb.Int32(0)).Default("value", 50)
+		// act
+		err := p.E(context.Background())
+		clampedErr := clamped.Err()
+		// assert
+		assert.EqualError(t, err, "invalid default for user.first_name: a int can't be assigned to a string field\n"+
+			"invalid default for user.secondary_addresses: user.secondary_addresses is a repeated field\n"+
+			"invalid default for user.nickname: propl.v1.User has no field nickname\n"+
+			"invalid default for value: propl.v1.CreateUserRequest has no field value")
+		assert.NoError(t, clampedErr)
+	})
+
+	t.Run("it should not fill a oneof member when another member is set", func(t *testing.T) {
+		// arrange
+		set := structpb.NewNumberValue(1)
+		unset := &structpb.Value{}
+		p := func(v *structpb.Value) *Propl[*structpb.Value] {
+			return For(v).Default("string_value", "none")
+		}
+		// act
+		setErr := p(set).E(context.Background())
+		unsetErr := p(unset).E(context.Background())
+		// assert
+		assert.NoError(t, setErr)
+		assert.NoError(t, unsetErr)
+		assert.Equal(t, float64(1), set.GetNumberValue())
+		assert.Equal(t, "none", unset.GetStringValue())
+	})
+
+	t.Run("it should report defaults for a nil message of an interface type", func(t *testing.T) {
+		// arrange
+		p := For[proto.Message](nil).Default("user.first_name", "bob")
+		// act
+		err := p.Err()
+		// assert
+		assert.EqualError(t, err, "invalid default for user.first_name: "+
+			"policies declared for a nil message have no descriptor, use ForDescriptor")
+	})
+}
+
+func TestSensitive(t *testing.T) {
+	t.Run("it should report the value of the field", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				FirstName: "bob",
+				LastName:  "loblaw",
+			},
+		}
+		p := For(req).
+			FieldPolicy("user.first_name", EqualsField("user.last_name"), InMessage).
+			FieldPolicy("user.last_name", EqualsField("user.first_name"), InMessage).
+			Sensitive("user.last_name")
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "bob", res.Infractions[0].Value)
+		assert.Equal(t, Redacted, res.Infractions[1].Value)
+	})
+
+	t.Run("it should redact fields with the debug_redact option", func(t *testing.T) {
+		// arrange
+		library := testLibrary(t)
+		req := newTestMessage(library, "UpdateBookRequest")
+		setTestField(req, []protoreflect.Name{"book", "token"}, protoreflect.ValueOfString("secret"))
+		setTestField(req, []protoreflect.Name{"book", "title"}, protoreflect.ValueOfString("dune"))
+		p := For(req).
+			FieldPolicy("book.token", EqualsField("book.isbn"), InMessage).
+			FieldPolicy("book.title", EqualsField("book.isbn"), InMessage)
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, Redacted, res.Infractions[0].Value)
+		assert.Equal(t, "dune", res.Infractions[1].Value)
+	})
+
+	t.Run("it should redact values reported by a resource policy set", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{
+				FirstName: "bob",
+			},
+		}
+		users := For[*proplv1.User](nil).
+			FieldPolicy("first_name", EqualsField("last_name"), InMessage)
+		p := For(req, "first_name").
+			ValidateMerged("user", func(ctx context.Context) (proto.Message, error) {
+				return &proplv1.User{LastName: "loblaw"}, nil
+			}, users).
+			Sensitive("user")
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user.first_name"}, res.Infractions.Paths())
+		assert.Equal(t, Redacted, res.Infractions[0].Value)
+	})
+}
+
+func TestLocalizedMessages(t *testing.T) {
+	catalog := CatalogMap{
+		"fr": {
+			MessageConditions:   "le champ ne remplit pas les conditions {conditions}",
+			MessageAtLeastOneOf: "au moins un de {paths} doit être défini",
+		},
+	}
+	req := &proplv1.CreateUserRequest{
+		User: &proplv1.User{
+			FirstName: "bob",
+		},
+	}
+	p := For(req).
+		NeverZero("user.id").
+		FieldPolicy("user.first_name", EqualsField("user.last_name"), InMessage).
+		AtLeastOneOf([]string{"user.primary_address", "user.secondary_addresses"}).
+		WithCatalog(catalog)
+
+	t.Run("it should render messages in the locale of the context", func(t *testing.T) {
+		// arrange
+		ctx := WithAcceptLanguage(context.Background(), "fr-CA, en;q=0.8")
+		// act
+		res, err := p.Check(ctx)
+		// assert
+		assert.NoError(t, err)
+		assert.EqualError(t, res.Infractions[0].Err, "le champ ne remplit pas les conditions InMessage, InMask")
+		assert.EqualError(t, res.Infractions[1].Err, "user.first_name should be equal to user.last_name")
+		assert.EqualError(t, res.Infractions[2].Err, "au moins un de user.primary_address, user.secondary_addresses doit être défini")
+		var re *RuleError
+		assert.ErrorAs(t, res.Infractions[2].Err, &re)
+		assert.Equal(t, MessageAtLeastOneOf, re.ID)
+		assert.Equal(t, map[string]string{"paths": "user.primary_address, user.secondary_addresses"}, re.Params)
+	})
+
+	t.Run("it should return a copy of the built-in catalog", func(t *testing.T) {
+		// arrange
+		catalog := English()
+		// act
+		catalog["en"][MessageNotZero] = "required"
+		// assert
+		assert.Equal(t, "it should not be zero", newRuleError(MessageNotZero).Error())
+		assert.Equal(t, "it should not be zero", English()["en"][MessageNotZero])
+	})
+
+	t.Run("it should render messages in English by default", func(t *testing.T) {
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.EqualError(t, res.Infractions[0].Err, "subject did not meet conditions InMessage, InMask")
+	})
+}
+
+func TestMessagesAndCodes(t *testing.T) {
+	t.Run("it should report the declared message and code", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				FirstName: "bob",
+			},
+		}
+		p := For(req).
+			NeverZero("user.id", WithCode("USER_ID_REQUIRED")).
+			FieldPolicy("user.first_name", EqualsField("user.last_name"), InMessage,
+				WithMessage("{path} must match {other}"), WithCode("NAME_MISMATCH")).
+			CustomEval("user.first_name", func(msg *proplv1.CreateUserRequest) error {
+				return errors.New("not bob")
+			}, WithMessage("must not be bob")).
+			AtLeastOneOf([]string{"user.primary_address", "user.secondary_addresses"}, WithCode("ADDRESS_REQUIRED"))
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "USER_ID_REQUIRED", res.Infractions[0].Code)
+		assert.EqualError(t, res.Infractions[1].Err, "user.first_name must match user.last_name")
+		assert.EqualError(t, res.Infractions[2].Err, "must not be bob")
+		assert.Empty(t, res.Infractions[2].Code)
+		assert.Equal(t, []Violation{
+			{Field: "user.id", Description: "subject did not meet conditions InMessage, InMask", Reason: "USER_ID_REQUIRED"},
+			{Field: "user.first_name", Description: "user.first_name must match user.last_name", Reason: "NAME_MISMATCH"},
+			{Field: "user.first_name", Description: "must not be bob"},
+			{Field: "user.primary_address", Description: "at least one of user.primary_address, user.secondary_addresses must be set", Reason: "ADDRESS_REQUIRED"},
+			{Field: "user.secondary_addresses", Description: "at least one of user.primary_address, user.secondary_addresses must be set", Reason: "ADDRESS_REQUIRED"},
+		}, res.Infractions.Violations())
+	})
+
+	t.Run("it should not translate declared messages", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{}
+		p := For(req).
+			NeverZero("user.id", WithMessage("id is required")).
+			WithCatalog(CatalogMap{"fr": {MessageConditions: "conditions {conditions}"}})
+		// act
+		res, err := p.Check(WithAcceptLanguage(context.Background(), "fr"))
+		// assert
+		assert.NoError(t, err)
+		assert.EqualError(t, res.Infractions[0].Err, "id is required")
+	})
+}
+
+type recordingHooks struct {
+	mu      sync.Mutex
+	started []string
+	results map[string]PolicyResult
+	done    []EvaluationInfo
+	// doneInStartContext is set when OnEvaluateDone receives the context returned
+	// by OnEvaluateStart
+	doneInStartContext bool
+}
+
+type recordingHooksKey struct{}
+
+func (h *recordingHooks) OnEvaluateStart(ctx context.Context, _ EvaluationInfo) context.Context {
+	return context.WithValue(ctx, recordingHooksKey{}, h)
+}
+
+func (h *recordingHooks) OnPolicyStart(_ context.Context, policy PolicyInfo) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.started = append(h.started, policy.Rule+" "+policy.Path)
+}
+
+func (h *recordingHooks) OnPolicyResult(_ context.Context, policy PolicyInfo, result PolicyResult) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.results == nil {
+		h.results = make(map[string]PolicyResult)
+	}
+	h.results[policy.Rule+" "+policy.Path] = result
+}
+
+func (h *recordingHooks) OnEvaluateDone(ctx context.Context, evaluation EvaluationInfo, _ *Result, _ error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.done = append(h.done, evaluation)
+	h.doneInStartContext = ctx.Value(recordingHooksKey{}) == h
+}
+
+type recordingMetrics struct {
+	counts    map[MetricKey]map[Action]int
+	latencies map[MetricKey]int
+}
+
+func (m *recordingMetrics) CountPolicy(key MetricKey, action Action, infraction bool) {
+	if m.counts[key] == nil {
+		m.counts[key] = make(map[Action]int)
+	}
+	m.counts[key][action]++
+}
+
+func (m *recordingMetrics) ObservePolicyLatency(key MetricKey, d time.Duration) {
+	m.latencies[key]++
+}
+
+func TestHooks(t *testing.T) {
+	t.Run("it should call the hooks for each policy", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{
+				FirstName: "bob",
+			},
+		}
+		hooks := &recordingHooks{}
+		p := For(req, "first_name").
+			NeverZero("user.id").
+			NeverZeroWhen("user.first_name", InMask).
+			NeverZeroWhen("user.last_name", InMask).
+			CustomEval("user.first_name", func(msg *proplv1.UpdateUserRequest) error {
+				return errors.New("not bob")
+			}, When("user.last_name", IsSet())).
+			WithHooks(hooks)
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.Error(t, err)
+		assert.Equal(t, []string{
+			"NotZero user.id",
+			"NotZero user.first_name",
+			"NotZero user.last_name",
+			"CustomEval user.first_name",
+		}, hooks.started)
+		assert.Equal(t, Fail, hooks.results["NotZero user.id"].Action)
+		assert.Error(t, hooks.results["NotZero user.id"].Err)
+		assert.Equal(t, Check, hooks.results["NotZero user.first_name"].Action)
+		assert.NoError(t, hooks.results["NotZero user.first_name"].Err)
+		assert.Equal(t, Skip, hooks.results["NotZero user.last_name"].Action)
+		assert.Equal(t, Skip, hooks.results["CustomEval user.first_name"].Action)
+		assert.Equal(t, []EvaluationInfo{{
+			Message:  "propl.v1.UpdateUserRequest",
+			Policies: 4,
+			Mode:     CollectAll,
+			Duration: hooks.done[0].Duration,
+		}}, hooks.done)
+		assert.True(t, hooks.doneInStartContext)
+	})
+
+	t.Run("it should record metrics by message, path and rule", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				FirstName: "bob",
+			},
+		}
+		metrics := &recordingMetrics{
+			counts:    make(map[MetricKey]map[Action]int),
+			latencies: make(map[MetricKey]int),
+		}
+		p := For(req).
+			NeverZero("user.first_name").
+			AtLeastOneOf([]string{"user.first_name", "user.last_name"}).
+			WithHooks(MetricsHooks(metrics))
+		// act
+		err := p.E(context.Background())
+		errAgain := p.E(context.Background())
+		// assert
+		assert.NoError(t, errors.Join(err, errAgain))
+		nameKey := MetricKey{Message: "propl.v1.CreateUserRequest", Path: "user.first_name", Rule: "NotZero"}
+		groupKey := MetricKey{Message: "propl.v1.CreateUserRequest", Path: "AtLeastOneOf(user.first_name, user.last_name)", Rule: "AtLeastOneOf"}
+		assert.Equal(t, map[MetricKey]map[Action]int{
+			nameKey:  {Check: 2},
+			groupKey: {Check: 2},
+		}, metrics.counts)
+		assert.Equal(t, map[MetricKey]int{nameKey: 2, groupKey: 2}, metrics.latencies)
+	})
+
+	t.Run("it should log infractions with redacted values", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				FirstName: "bob",
+				LastName:  "loblaw",
+			},
+		}
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+		p := For(req).
+			NeverZero("user.id", WithCode("USER_ID_REQUIRED")).
+			FieldPolicy("user.last_name", EqualsField("user.first_name"), InMessage, WithSeverity(SeverityWarning)).
+			Sensitive("user.last_name").
+			WithHooks(LogHooks(logger))
+		// act
+		_, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		var records []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var record map[string]any
+			assert.NoError(t, json.Unmarshal([]byte(line), &record))
+			records = append(records, record)
+		}
+		assert.Equal(t, []map[string]any{
+			{
+				"level":    "WARN",
+				"msg":      "propl infraction",
+				"message":  "propl.v1.CreateUserRequest",
+				"path":     "user.id",
+				"severity": "error",
+				"code":     "USER_ID_REQUIRED",
+				"error":    "subject did not meet conditions InMessage, InMask",
+			},
+			{
+				"level":    "INFO",
+				"msg":      "propl infraction",
+				"message":  "propl.v1.CreateUserRequest",
+				"path":     "user.last_name",
+				"severity": "warning",
+				"value":    Redacted,
+				"error":    "user.last_name should be equal to user.first_name",
+			},
+		}, records)
+	})
+}
+
+func TestExplain(t *testing.T) {
+	t.Run("it should trace how each policy was evaluated", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{
+				FirstName: "bob",
+				LastName:  "loblaw",
+			},
+		}
+		chain := (&trait{traitType: FieldEqual, otherPath: "user.last_name"}).
+			or((&trait{traitType: NotZero}).and(&trait{traitType: FieldNotEqual, otherPath: "user.last_name"}))
+		p := For(req, "first_name").
+			NeverZero("user.id").
+			NeverZeroWhen("user.last_name", InMask).
+			FieldPolicy("user.first_name", chain, InMask).
+			CustomEval("user.first_name", func(msg *proplv1.UpdateUserRequest) error {
+				return nil
+			}, When("user.id", IsSet()))
+		// act
+		explanation, err := p.Explain(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, explanation.Result.Failed())
+		assert.Equal(t, []*PolicyTrace{
+			{
+				Path:       "user.id",
+				Rule:       "NotZero",
+				Fields:     []FieldTrace{{Path: "user.id"}},
+				Conditions: "InMessage, InMask",
+				Action:     Fail,
+				Outcome:    OutcomeFailed,
+				Error:      "subject did not meet conditions InMessage, InMask",
+			},
+			{
+				Path:       "user.last_name",
+				Rule:       "NotZero",
+				Fields:     []FieldTrace{{Path: "user.last_name", InMessage: true}},
+				Conditions: "InMask",
+				Action:     Skip,
+				Outcome:    OutcomeSkipped,
+			},
+			{
+				Path:       "user.first_name",
+				Rule:       "FieldEqual",
+				Fields:     []FieldTrace{{Path: "user.first_name", InMessage: true, InMask: true}},
+				Conditions: "InMask",
+				Action:     Check,
+				Traits: []TraitTrace{
+					{Type: FieldEqual, Other: "user.last_name"},
+					{Type: NotZero, Link: "or", Held: true},
+					{Type: FieldNotEqual, Link: "and", Other: "user.last_name", Held: true},
+				},
+				Outcome: OutcomePassed,
+			},
+			{
+				Path:    "user.first_name",
+				Rule:    "CustomEval",
+				Fields:  []FieldTrace{{Path: "user.first_name", InMessage: true, InMask: true}},
+				Action:  Skip,
+				Outcome: OutcomeSkipped,
+			},
+		}, explanation.Policies)
+	})
+
+	t.Run("it should print the trace as text and JSON", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{},
+		}
+		p := For(req, "first_name").
+			NeverZero("user.first_name").
+			NeverZero("user.last_name").
+			WithEvaluationMode(FailFast)
+		// act
+		explanation, err := p.Explain(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, `propl.v1.UpdateUserRequest (mask: first_name)
+NotZero user.first_name: failed: subject did not meet conditions InMessage, InMask [action: Fail, severity: error, conditions: InMessage, InMask]
+  field user.first_name: in message: false, in mask: true
+NotZero user.last_name: not evaluated [action: Fail, severity: error, conditions: InMessage, InMask]
+  field user.last_name: in message: false, in mask: false
+`, explanation.String())
+		b, err := json.Marshal(explanation.Policies[1])
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{
+			"path": "user.last_name",
+			"rule": "NotZero",
+			"fields": [{"path": "user.last_name", "in_message": false, "in_mask": false}],
+			"conditions": "InMessage, InMask",
+			"severity": "error",
+			"action": "Fail",
+			"outcome": "not evaluated"
+		}`, string(b))
+	})
+
+	t.Run("it should unmarshal from JSON", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{
+				FirstName: "bob",
+			},
+		}
+		p := For(req, "first_name").
+			FieldPolicy("user.first_name", NotEqualsField("user.last_name"), InMask, WithSeverity(SeverityWarning)).
+			NeverZeroWhen("user.last_name", InMask)
+		explanation, err := p.Explain(context.Background())
+		assert.NoError(t, err)
+		b, err := json.Marshal(explanation)
+		assert.NoError(t, err)
+		// act
+		var decoded Explanation
+		err = json.Unmarshal(b, &decoded)
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, explanation.Policies, decoded.Policies)
+		assert.Equal(t, SeverityWarning, decoded.Policies[0].Severity)
+		assert.Equal(t, FieldNotEqual, decoded.Policies[0].Traits[0].Type)
+		assert.Equal(t, Skip, decoded.Policies[1].Action)
+	})
+
+	t.Run("it should not unmarshal unknown names", func(t *testing.T) {
+		// act
+		var trace PolicyTrace
+		err := json.Unmarshal([]byte(`{"severity": "fatal"}`), &trace)
+		// assert
+		assert.ErrorContains(t, err, `unknown propl.Severity "fatal"`)
+	})
+
+	t.Run("it should not have side effects", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{
+				FirstName: "  bob ",
+			},
+		}
+		hooks := &recordingHooks{}
+		var loaded, warned bool
+		load := func(ctx context.Context) (proto.Message, error) {
+			loaded = true
+			return &proplv1.User{}, nil
+		}
+		p := For(req, "first_name").
+			Default("user.last_name", "loblaw").
+			Normalize("user.first_name", TrimSpace()).
+			NeverZero("user.id", WithSeverity(SeverityWarning)).
+			Immutable("user.first_name", load).
+			WithHooks(hooks).
+			WithWarningsHandler(func(ctx context.Context, warnings FieldInfractions) {
+				warned = true
+			})
+		// act
+		explanation, err := p.Explain(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "  bob ", req.GetUser().GetFirstName())
+		assert.Empty(t, req.GetUser().GetLastName())
+		assert.Empty(t, hooks.started)
+		assert.Empty(t, hooks.done)
+		assert.False(t, loaded)
+		assert.False(t, warned)
+		assert.Equal(t, OutcomeNotEvaluated, explanation.Policies[1].Outcome)
+	})
+
+	t.Run("it should trace errors with their declared and localized messages", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{},
+		}
+		p := For(req).
+			NeverZeroWhen("user.id", InMessage, WithMessage("an id is required")).
+			NeverZeroWhen("user.first_name", InMessage).
+			WithCatalog(CatalogMap{"fr": {MessageConditions: "conditions {conditions}"}})
+		// act
+		explanation, err := p.Explain(WithAcceptLanguage(context.Background(), "fr"))
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "an id is required", explanation.Policies[0].Error)
+		assert.Equal(t, "conditions InMessage", explanation.Policies[1].Error)
+	})
+}
+
+func TestDescribe(t *testing.T) {
+	p := For[*proplv1.CreateUserRequest](nil).
+		NeverZero("user.first_name", WithCode("FIRST_NAME_REQUIRED")).
+		FieldPolicy("user.id", ResourceName("users/{user}", "orgs/{org}/users/{user}"), InMessage).
+		FieldPolicy("user.last_name", NotEqualsField("user.first_name"), InMessage, WithSeverity(SeverityWarning)).
+		NeverZero("user.secondary_addresses", When("user.primary_address", IsSet())).
+		AtLeastOneOf([]string{"user.first_name", "user.last_name"}, WithMessage("a name is required"))
+
+	t.Run("it should describe each policy", func(t *testing.T) {
+		// act
+		d, err := p.Describe()
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, protoreflect.FullName("propl.v1.CreateUserRequest"), d.Message)
+		assert.Equal(t, []PolicyDescription{
+			{
+				Path:       "user.first_name",
+				Rule:       "NotZero",
+				Traits:     []TraitDescription{{Type: NotZero}},
+				Conditions: "InMessage, InMask",
+				Code:       "FIRST_NAME_REQUIRED",
+				Messages:   []string{"subject did not meet conditions InMessage, InMask", "it should not be zero"},
+				conditions: InMessage | InMask,
+			},
+			{
+				Path:       "user.id",
+				Rule:       "ResourceNamePattern",
+				Traits:     []TraitDescription{{Type: ResourceNamePattern, Other: "users/{user}, orgs/{org}/users/{user}"}},
+				Conditions: "InMessage",
+				Messages:   []string{"subject did not meet conditions InMessage", "it should be a resource name matching users/{user} or orgs/{org}/users/{user}"},
+				conditions: InMessage,
+			},
+			{
+				Path:       "user.last_name",
+				Rule:       "FieldNotEqual",
+				Traits:     []TraitDescription{{Type: FieldNotEqual, Other: "user.first_name"}},
+				Conditions: "InMessage",
+				Severity:   SeverityWarning,
+				Messages:   []string{"subject did not meet conditions InMessage", "user.last_name should not be equal to user.first_name"},
+				conditions: InMessage,
+			},
+			{
+				Path:       "user.secondary_addresses",
+				Rule:       "NotZero",
+				Traits:     []TraitDescription{{Type: NotZero}},
+				Conditions: "InMessage, InMask",
+				When:       []string{"user.primary_address"},
+				Messages:   []string{"subject did not meet conditions InMessage, InMask", "it should not be zero"},
+				conditions: InMessage | InMask,
+			},
+			{
+				Path:       "AtLeastOneOf(user.first_name, user.last_name)",
+				Paths:      []string{"user.first_name", "user.last_name"},
+				Rule:       "AtLeastOneOf",
+				Conditions: "InMessage",
+				Messages:   []string{"a name is required"},
+				conditions: InMessage,
+			},
+		}, d.Policies)
+	})
+
+	t.Run("it should render the policies as Markdown", func(t *testing.T) {
+		// act
+		d, err := p.Describe()
+		md := d.Markdown()
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "### propl.v1.CreateUserRequest\n\n"+
+			"| Path | Rule | Conditions | When | Severity | Code | Messages |\n"+
+			"| --- | --- | --- | --- | --- | --- | --- |\n"+
+			"| `user.first_name` | NotZero | InMessage, InMask |  | error | `FIRST_NAME_REQUIRED` | subject did not meet conditions InMessage, InMask<br>it should not be zero |\n"+
+			"| `user.id` | ResourceNamePattern(users/{user}, orgs/{org}/users/{user}) | InMessage |  | error |  | subject did not meet conditions InMessage<br>it should be a resource name matching users/{user} or orgs/{org}/users/{user} |\n"+
+			"| `user.last_name` | FieldNotEqual(user.first_name) | InMessage |  | warning |  | subject did not meet conditions InMessage<br>user.last_name should not be equal to user.first_name |\n"+
+			"| `user.secondary_addresses` | NotZero | InMessage, InMask | user.primary_address | error |  | subject did not meet conditions InMessage, InMask<br>it should not be zero |\n"+
+			"| `AtLeastOneOf(user.first_name, user.last_name)` | AtLeastOneOf | InMessage |  | error |  | a name is required |\n", md)
+	})
+
+	t.Run("it should unmarshal from JSON", func(t *testing.T) {
+		// arrange
+		d, err := p.Describe()
+		assert.NoError(t, err)
+		b, err := json.Marshal(d)
+		assert.NoError(t, err)
+		// act
+		var decoded Description
+		err = json.Unmarshal(b, &decoded)
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, SeverityWarning, decoded.Policies[2].Severity)
+		assert.Equal(t, FieldNotEqual, decoded.Policies[2].Traits[0].Type)
+		reencoded, err := json.Marshal(decoded)
+		assert.NoError(t, err)
+		assert.JSONEq(t, string(b), string(reencoded))
+	})
+
+	t.Run("it should annotate the JSON schema of the message", func(t *testing.T) {
+		// act
+		d, err := p.Describe()
+		schema := d.JSONSchema()
+		// assert
+		assert.NoError(t, err)
+		b, err := json.Marshal(schema)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{
+			"type": "object",
+			"properties": {
+				"user": {
+					"type": "object",
+					"required": ["firstName", "id"],
+					"properties": {
+						"firstName": {"minLength": 1},
+						"id": {"pattern": "^(users/[^/]+|orgs/[^/]+/users/[^/]+)$"}
+					}
+				}
+			}
+		}`, string(b))
+	})
+
+	t.Run("it should describe groups by their rule", func(t *testing.T) {
+		// arrange
+		groups := For[*proplv1.User](nil).
+			MutuallyExclusive([]string{"primary_address", "secondary_addresses"})
+		// act
+		d, err := groups.Describe()
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"only one of primary_address, secondary_addresses may be set"}, d.Policies[0].Messages)
+	})
+
+	t.Run("it should annotate the items of repeated messages", func(t *testing.T) {
+		// arrange
+		addresses := For[*proplv1.User](nil).
+			NeverZero("secondary_addresses").
+			NeverZero("secondary_addresses.line1")
+		// act
+		d, err := addresses.Describe()
+		schema := d.JSONSchema()
+		// assert
+		assert.NoError(t, err)
+		b, err := json.Marshal(schema)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{
+			"type": "object",
+			"required": ["secondaryAddresses"],
+			"properties": {
+				"secondaryAddresses": {
+					"type": "array",
+					"minItems": 1,
+					"items": {
+						"type": "object",
+						"required": ["line1"],
+						"properties": {
+							"line1": {"minLength": 1}
+						}
+					}
+				}
+			}
+		}`, string(b))
+	})
+}
+
+func TestRegistry(t *testing.T) {
+	userSet := For[*proplv1.User](nil).NeverZero("id")
+	createSet := For[*proplv1.CreateUserRequest](nil).NeverZero("user.id")
+
+	t.Run("it should validate messages with their registered policy set", func(t *testing.T) {
+		// arrange
+		reg := NewRegistry()
+		reg.Register("propl.v1.User", userSet)
+		reg.Register("propl.v1.CreateUserRequest", createSet)
+		// act
+		userErr := reg.Validate(context.Background(), &proplv1.User{})
+		createRes, createErr := reg.Check(context.Background(), &proplv1.CreateUserRequest{User: &proplv1.User{Id: "1"}})
+		// assert
+		assert.Error(t, userErr)
+		assert.NoError(t, createErr)
+		assert.False(t, createRes.Failed())
+	})
+
+	t.Run("it should prefer the policy set of the method", func(t *testing.T) {
+		// arrange
+		reg := NewRegistry()
+		reg.Register("propl.v1.User", userSet)
+		reg.RegisterMethod("/propl.v1.UserService/ImportUser", "propl.v1.User", For[*proplv1.User](nil))
+		msg := &proplv1.User{}
+		// act
+		err := reg.Validate(context.Background(), msg)
+		importErr := reg.Validate(WithMethod(context.Background(), "/propl.v1.UserService/ImportUser"), msg)
+		otherErr := reg.Validate(WithMethod(context.Background(), "/propl.v1.UserService/CreateUser"), msg)
+		// assert
+		assert.Error(t, err)
+		assert.NoError(t, importErr)
+		assert.Error(t, otherErr)
+	})
+
+	t.Run("it should treat unregistered messages as configured", func(t *testing.T) {
+		// arrange
+		var buf bytes.Buffer
+		msg := &proplv1.Address{}
+		// act
+		allowErr := NewRegistry().Validate(context.Background(), msg)
+		denyErr := NewRegistry().WithUnregistered(DenyUnregistered).Validate(context.Background(), msg)
+		warnErr := NewRegistry().
+			WithUnregistered(WarnUnregistered).
+			WithLogger(slog.New(slog.NewTextHandler(&buf, nil))).
+			Validate(context.Background(), msg)
+		// assert
+		assert.NoError(t, allowErr)
+		assert.ErrorIs(t, denyErr, ErrUnregistered)
+		assert.NoError(t, warnErr)
+		assert.Contains(t, buf.String(), "message=propl.v1.Address")
+	})
+
+	t.Run("it should be safe for concurrent use", func(t *testing.T) {
+		// arrange
+		reg := NewRegistry().WithUnregistered(DenyUnregistered)
+		var wg sync.WaitGroup
+		// act
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				reg.Register("propl.v1.User", userSet)
+			}()
+			go func() {
+				defer wg.Done()
+				_, _ = reg.Check(context.Background(), &proplv1.User{Id: "1"})
+			}()
+		}
+		wg.Wait()
+		res, err := reg.Check(context.Background(), &proplv1.User{Id: "1"})
+		// assert
+		assert.NoError(t, err)
+		assert.False(t, res.Failed())
+	})
+
+	t.Run("it should register policy sets by type in the default registry", func(t *testing.T) {
+		// arrange
+		defer func(reg *Registry) { DefaultRegistry = reg }(DefaultRegistry)
+		DefaultRegistry = NewRegistry()
+		Register[*proplv1.User](userSet)
+		// act
+		err := Validate(context.Background(), &proplv1.User{})
+		// assert
+		assert.Error(t, err)
+	})
+}
+
+func TestForDescriptor(t *testing.T) {
+	library := testLibrary(t)
+	book := library.Messages().ByName("Book")
+	p := ForDescriptor(book).
+		NeverZeroWhen("title", InMask).
+		FieldPolicy("isbn", NotEqualsField("title"), InMessage).
+		FieldPolicy("author.display_name", NotEqualsField("title"), InMessage).
+		CustomEvalWhen("title", InMask, func(msg proto.Message) error {
+			title := msg.ProtoReflect().Get(book.Fields().ByName("title")).String()
+			if title == "untitled" {
+				return errors.New("it should have a title")
+			}
+			return nil
+		})
+
+	t.Run("it should evaluate dynamic messages", func(t *testing.T) {
+		// arrange
+		msg := dynamicpb.NewMessage(book)
+		setTestField(msg, []protoreflect.Name{"isbn"}, protoreflect.ValueOfString("untitled"))
+		setTestField(msg, []protoreflect.Name{"title"}, protoreflect.ValueOfString("untitled"))
+		setTestField(msg, []protoreflect.Name{"author", "display_name"}, protoreflect.ValueOfString("frank"))
+		// act
+		res, err := p.CheckReflect(context.Background(), msg, "title")
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"isbn", "title"}, res.Infractions.Paths())
+		assert.Equal(t, "isbn should not be equal to title", res.Infractions[0].Err.Error())
+		assert.Equal(t, "untitled", res.Infractions[0].Value)
+	})
+
+	t.Run("it should honor the mask of dynamic messages", func(t *testing.T) {
+		// arrange
+		msg := dynamicpb.NewMessage(book)
+		setTestField(msg, []protoreflect.Name{"isbn"}, protoreflect.ValueOfString("0441013597"))
+		setTestField(msg, []protoreflect.Name{"author", "display_name"}, protoreflect.ValueOfString("frank"))
+		// act
+		inMask, err := p.CheckReflect(context.Background(), msg, "title")
+		notInMask, notInMaskErr := p.CheckReflect(context.Background(), msg)
+		// assert
+		assert.NoError(t, errors.Join(err, notInMaskErr))
+		assert.Equal(t, []string{"title"}, inMask.Infractions.Paths())
+		assert.False(t, notInMask.Failed())
+	})
+
+	t.Run("it should explain dynamic messages", func(t *testing.T) {
+		// arrange
+		msg := dynamicpb.NewMessage(book)
+		setTestField(msg, []protoreflect.Name{"isbn"}, protoreflect.ValueOfString("0441013597"))
+		setTestField(msg, []protoreflect.Name{"author", "display_name"}, protoreflect.ValueOfString("frank"))
+		// act
+		explanation, err := p.ExplainReflect(context.Background(), msg, "title")
+		_, explainErr := p.Explain(context.Background())
+		_, otherErr := p.ExplainMessage(context.Background(), newTestMessage(library, "Author"))
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, protoreflect.FullName("propl.test.v1.Book"), explanation.Message)
+		assert.Equal(t, []string{"title"}, explanation.Mask)
+		assert.Equal(t, []string{"title"}, explanation.Result.Infractions.Paths())
+		assert.Equal(t, OutcomeFailed, explanation.Policies[0].Outcome)
+		assert.Equal(t, OutcomePassed, explanation.Policies[1].Outcome)
+		assert.Error(t, explainErr)
+		assert.EqualError(t, otherErr, "policies for propl.test.v1.Book cannot evaluate propl.test.v1.Author")
+	})
+
+	t.Run("it should not evaluate messages of another type", func(t *testing.T) {
+		// act
+		_, err := p.CheckReflect(context.Background(), newTestMessage(library, "Author"))
+		_, checkErr := p.Check(context.Background())
+		// assert
+		assert.EqualError(t, err, "policies for propl.test.v1.Book cannot evaluate propl.test.v1.Author")
+		assert.Error(t, checkErr)
+	})
+
+	t.Run("it should not evaluate dynamic messages of another type than the declared message", func(t *testing.T) {
+		// arrange
+		books := For(newTestMessage(library, "Book")).NeverZero("title")
+		// act
+		_, err := books.CheckMessage(context.Background(), newTestMessage(library, "Author"))
+		// assert
+		assert.EqualError(t, err, "policies for propl.test.v1.Book cannot evaluate propl.test.v1.Author")
+	})
+
+	t.Run("it should describe policies without a message", func(t *testing.T) {
+		// act
+		d, err := ForDescriptor(library.Messages().ByName("UpdateBookRequest")).
+			FieldBehaviorPolicies(Update).
+			Describe()
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, protoreflect.FullName("propl.test.v1.UpdateBookRequest"), d.Message)
+		assert.NotEmpty(t, d.Policies)
+	})
+}
+
+func TestSubMessagePolicies(t *testing.T) {
+	t.Run("it should apply the policy set to each occurrence of the type", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{
+				PrimaryAddress: &proplv1.Address{},
+				SecondaryAddresses: []*proplv1.Address{
+					{Line1: "1 main st"},
+					{Line2: "apt 2"},
+				},
+			},
+		}
+		addresses := For[*proplv1.Address](nil).NeverZeroWhen("line1", InMask)
+		p := For(req, "user.primary_address.line1", "user.secondary_addresses.line1").
+			SubMessagePolicies("propl.v1.Address", addresses)
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"user.primary_address.line1",
+			"user.secondary_addresses[1].line1",
+		}, res.Infractions.Paths())
+	})
+
+	t.Run("it should walk recursive types and map values", func(t *testing.T) {
+		// arrange
+		node := testTree(t).Messages().ByName("Node")
+		newNode := func(name string) *dynamicpb.Message {
+			n := dynamicpb.NewMessage(node)
+			if name != "" {
+				n.Set(node.Fields().ByName("name"), protoreflect.ValueOfString(name))
+			}
+			return n
+		}
+		root := newNode("root")
+		parent := newNode("")
+		parent.Set(node.Fields().ByName("parent"), protoreflect.ValueOfMessage(newNode("grandparent")))
+		root.Set(node.Fields().ByName("parent"), protoreflect.ValueOfMessage(parent))
+		children := root.Mutable(node.Fields().ByName("children")).List()
+		children.Append(protoreflect.ValueOfMessage(newNode("")))
+		child := newNode("child")
+		child.Mutable(node.Fields().ByName("links")).Map().Set(
+			protoreflect.ValueOfString("next").MapKey(), protoreflect.ValueOfMessage(newNode("")))
+		children.Append(protoreflect.ValueOfMessage(child))
+		nodes := ForDescriptor(node).NeverZero("name")
+		nodes.SubMessagePolicies(node.FullName(), nodes)
+		// act
+		res, err := nodes.CheckReflect(context.Background(), root)
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"parent.name",
+			"children[0].name",
+			"children[1].links[next].name",
+		}, res.Infractions.Paths())
+	})
+
+	t.Run("it should normalize each occurrence before evaluating policies concurrently", func(t *testing.T) {
+		// arrange
+		req := &proplv1.UpdateUserRequest{
+			User: &proplv1.User{
+				PrimaryAddress: &proplv1.Address{Line1: "  1 main st "},
+				SecondaryAddresses: []*proplv1.Address{
+					{Line1: " 2 main st"},
+					{Line1: "3 main st  "},
+				},
+			},
+		}
+		addresses := For[*proplv1.Address](nil).
+			Normalize("line1", TrimSpace()).
+			NeverZero("line1")
+		trimmed := func(msg *proplv1.UpdateUserRequest) error {
+			lines := []string{msg.GetUser().GetPrimaryAddress().GetLine1()}
+			for _, a := range msg.GetUser().GetSecondaryAddresses() {
+				lines = append(lines, a.GetLine1())
+			}
+			for _, line := range lines {
+				if line != strings.TrimSpace(line) {
+					return fmt.Errorf("%q is not trimmed", line)
+				}
+			}
+			return nil
+		}
+		p := For(req).
+			WithConcurrency(4).
+			SubMessagePolicies("propl.v1.Address", addresses).
+			CustomEval("user.primary_address.line1", trimmed).
+			CustomEval("user.secondary_addresses", trimmed).
+			CustomEval("user", trimmed)
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.False(t, res.Failed())
+		assert.Equal(t, "1 main st", req.GetUser().GetPrimaryAddress().GetLine1())
+		assert.Equal(t, "2 main st", req.GetUser().GetSecondaryAddresses()[0].GetLine1())
+	})
+
+	t.Run("it should not report sub-message policies of different sets as duplicates", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				PrimaryAddress: &proplv1.Address{Line2: "apt 2"},
+			},
+		}
+		p := For(req).
+			WithDuplicatePolicyDetection().
+			SubMessagePolicies("propl.v1.Address", For[*proplv1.Address](nil).NeverZero("line1")).
+			SubMessagePolicies("propl.v1.Address", For[*proplv1.Address](nil).FieldPolicy("line2", NotEqualsField("line1"), InMessage))
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user.primary_address.line1"}, res.Infractions.Paths())
+	})
+
+	t.Run("it should redact sensitive fields of each occurrence", func(t *testing.T) {
+		// arrange
+		req := &proplv1.CreateUserRequest{
+			User: &proplv1.User{
+				SecondaryAddresses: []*proplv1.Address{{Line1: "1 main st", Line2: "1 main st"}},
+			},
+		}
+		addresses := For[*proplv1.Address](nil).FieldPolicy("line2", NotEqualsField("line1"), InMessage)
+		p := For(req).
+			SubMessagePolicies("propl.v1.Address", addresses).
+			Sensitive("user.secondary_addresses")
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "user.secondary_addresses[0].line2", res.Infractions[0].Path)
+		assert.Equal(t, Redacted, res.Infractions[0].Value)
+	})
+}
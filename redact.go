@@ -0,0 +1,118 @@
+package propl
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Redacted replaces the value of a sensitive field wherever propl reports it.
+const Redacted = "[REDACTED]"
+
+// Sensitive declares the field at the provided path, and any fields beneath it, as
+// sensitive. Their values are reported as Redacted. Fields with the debug_redact option
+// are always sensitive.
+func (r *Propl[T]) Sensitive(path string) *Propl[T] {
+	r.sensitive = append(r.sensitive, path)
+	return r
+}
+
+// isSensitive reports whether the field at path of the message, or a field along the
+// path, is declared sensitive or has the debug_redact option.
+func (r *Propl[T]) isSensitive(desc protoreflect.MessageDescriptor, path string) bool {
+	path = withoutIndexes(path)
+	for _, s := range r.sensitive {
+		if path == s || strings.HasPrefix(path, s+".") {
+			return true
+		}
+	}
+	for _, name := range strings.Split(path, ".") {
+		if desc == nil {
+			return false
+		}
+		fd := fieldByName(desc, name)
+		if fd == nil {
+			return false
+		}
+		if debugRedact(fd) {
+			return true
+		}
+		desc = fd.Message()
+	}
+	return false
+}
+
+// withoutIndexes drops the indexes and keys of repeated and map fields from a path,
+// e.g. "addresses[1].line1" becomes "addresses.line1".
+func withoutIndexes(path string) string {
+	var b strings.Builder
+	depth := 0
+	for _, c := range path {
+		switch {
+		case c == '[':
+			depth++
+		case c == ']' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// debugRedact reports whether the field has the debug_redact option.
+func debugRedact(fd protoreflect.FieldDescriptor) bool {
+	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
+	return ok && opts.GetDebugRedact()
+}
+
+// infractionValue formats the value of the field a policy failed on, redacted if the
+// field is sensitive. Only set scalar and enum fields have a value.
+func (r *Propl[T]) infractionValue(store *fieldStore[T], bp *boundPolicy) string {
+	if len(bp.paths) > 0 {
+		return ""
+	}
+	f := store.loadFieldsFromPath(bp.path).getByPath(bp.path)
+	if f == nil || f.d() == nil || !f.s() {
+		return ""
+	}
+	v := formatValue(f.d(), f.fv())
+	if v != "" && r.isSensitive(store.message().ProtoReflect().Descriptor(), bp.path) {
+		return Redacted
+	}
+	return v
+}
+
+// formatValue formats scalar and enum values. Messages, lists and maps are not formatted.
+func formatValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	if fd.IsList() || fd.IsMap() {
+		return ""
+	}
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return ""
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return fmt.Sprint(v.Enum())
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(v.Bytes())
+	default:
+		return v.String()
+	}
+}
+
+// redactInfractions redacts the values of infractions reported by another policy set on
+// fields that are sensitive in this one.
+func (r *Propl[T]) redactInfractions(desc protoreflect.MessageDescriptor, infractions FieldInfractions) FieldInfractions {
+	for i, fi := range infractions {
+		if fi.Value != "" && r.isSensitive(desc, fi.Path) {
+			infractions[i].Value = Redacted
+		}
+	}
+	return infractions
+}
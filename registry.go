@@ -0,0 +1,194 @@
+package propl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Unregistered is how a registry treats messages without a policy set.
+type Unregistered uint32
+
+const (
+	// AllowUnregistered accepts messages without a policy set.
+	AllowUnregistered Unregistered = iota
+	// DenyUnregistered rejects messages without a policy set with ErrUnregistered.
+	DenyUnregistered
+	// WarnUnregistered accepts messages without a policy set, logging a warning.
+	WarnUnregistered
+)
+
+// ErrUnregistered is returned for messages without a policy set by a registry that
+// denies them.
+var ErrUnregistered = errors.New("no policy set registered")
+
+// Registry holds a policy set for each message type, so any message can be validated
+// without knowing its type, e.g. in a gRPC interceptor. Messages reused by more than
+// one method can have a policy set for a method that overrides the message's. A
+// Registry is safe for concurrent use.
+type Registry struct {
+	mu           sync.RWMutex
+	sets         map[protoreflect.FullName]PolicySet
+	methods      map[methodKey]PolicySet
+	unregistered Unregistered
+	handler      OrderedFieldInfractionsHandler
+	logger       *slog.Logger
+}
+
+type methodKey struct {
+	method string
+	name   protoreflect.FullName
+}
+
+// NewRegistry creates an empty registry that allows unregistered messages.
+func NewRegistry() *Registry {
+	return &Registry{
+		sets:    make(map[protoreflect.FullName]PolicySet),
+		methods: make(map[methodKey]PolicySet),
+	}
+}
+
+// DefaultRegistry is the registry used by Register and Validate.
+var DefaultRegistry = NewRegistry()
+
+// WithUnregistered sets how messages without a policy set are treated. Defaults to
+// AllowUnregistered.
+func (reg *Registry) WithUnregistered(u Unregistered) *Registry {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.unregistered = u
+	return reg
+}
+
+// WithOrderedFieldInfractionsHandler specifies how Validate handles infractions.
+func (reg *Registry) WithOrderedFieldInfractionsHandler(f OrderedFieldInfractionsHandler) *Registry {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.handler = f
+	return reg
+}
+
+// WithLogger sets the logger unregistered messages are logged with when warning
+// about them. Defaults to slog.Default().
+func (reg *Registry) WithLogger(l *slog.Logger) *Registry {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.logger = l
+	return reg
+}
+
+// Register registers the policy set for messages with the full name, replacing any
+// policy set registered before.
+func (reg *Registry) Register(name protoreflect.FullName, set PolicySet) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.sets[name] = set
+}
+
+// RegisterMethod registers the policy set for messages with the full name in calls to
+// the method, e.g. "/propl.v1.UserService/UpdateUser". It overrides the message's
+// policy set when the evaluation context selects the method with WithMethod.
+func (reg *Registry) RegisterMethod(method string, name protoreflect.FullName, set PolicySet) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.methods[methodKey{method: method, name: name}] = set
+}
+
+// Lookup returns the policy set for messages with the full name in calls to the
+// method selected by ctx, if any.
+func (reg *Registry) Lookup(ctx context.Context, name protoreflect.FullName) (PolicySet, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	if method, ok := methodFromContext(ctx); ok {
+		if set, ok := reg.methods[methodKey{method: method, name: name}]; ok {
+			return set, true
+		}
+	}
+	set, ok := reg.sets[name]
+	return set, ok
+}
+
+// Check evaluates the policy set registered for the message's type against msg, with
+// maskPaths as the mask. Messages without a policy set have an empty result, unless
+// the registry denies them.
+func (reg *Registry) Check(ctx context.Context, msg proto.Message, maskPaths ...string) (*Result, error) {
+	name := msg.ProtoReflect().Descriptor().FullName()
+	set, ok := reg.Lookup(ctx, name)
+	if ok {
+		return set.CheckMessage(ctx, msg, maskPaths...)
+	}
+	reg.mu.RLock()
+	unregistered, logger := reg.unregistered, reg.logger
+	reg.mu.RUnlock()
+	switch unregistered {
+	case DenyUnregistered:
+		return nil, fmt.Errorf("%w for %s", ErrUnregistered, name)
+	case WarnUnregistered:
+		if logger == nil {
+			logger = slog.Default()
+		}
+		logger.LogAttrs(ctx, slog.LevelWarn, "propl policy set not registered", slog.String(LogMessageKey, string(name)))
+	}
+	return &Result{}, nil
+}
+
+// Validate is Check, returning an error describing the infractions if there are any.
+func (reg *Registry) Validate(ctx context.Context, msg proto.Message, maskPaths ...string) error {
+	res, err := reg.Check(ctx, msg, maskPaths...)
+	if err != nil {
+		return err
+	}
+	if len(res.Infractions) == 0 {
+		return nil
+	}
+	reg.mu.RLock()
+	handler := reg.handler
+	reg.mu.RUnlock()
+	if handler == nil {
+		handler = defaultFieldInfractionsHandler
+	}
+	return handler(res.Infractions)
+}
+
+// Register registers the policy set for messages of type T in the default registry,
+// e.g. Register[*v1.UpdateUserRequest](set).
+func Register[T proto.Message](set PolicySet) {
+	DefaultRegistry.Register(fullNameOf[T](), set)
+}
+
+// RegisterMethod registers the policy set for messages of type T in calls to the
+// method in the default registry.
+func RegisterMethod[T proto.Message](method string, set PolicySet) {
+	DefaultRegistry.RegisterMethod(method, fullNameOf[T](), set)
+}
+
+// Validate validates msg with the policy set registered for its type in the default
+// registry.
+func Validate(ctx context.Context, msg proto.Message, maskPaths ...string) error {
+	return DefaultRegistry.Validate(ctx, msg, maskPaths...)
+}
+
+// fullNameOf is the full name of the message type T.
+func fullNameOf[T proto.Message]() protoreflect.FullName {
+	var t T
+	return t.ProtoReflect().Descriptor().FullName()
+}
+
+type methodContextKey struct{}
+
+// WithMethod returns a context that selects the policy sets registered for the
+// method, e.g. the FullMethod of a gRPC call.
+func WithMethod(ctx context.Context, method string) context.Context {
+	return context.WithValue(ctx, methodContextKey{}, method)
+}
+
+// methodFromContext returns the method selected by ctx.
+func methodFromContext(ctx context.Context) (string, bool) {
+	method, ok := ctx.Value(methodContextKey{}).(string)
+	return method, ok
+}
@@ -0,0 +1,149 @@
+package propl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ResourceLoader loads the stored resource a request refers to, e.g. the user
+// being updated by an UpdateUserRequest. It may return nil if there is none.
+type ResourceLoader func(ctx context.Context) (proto.Message, error)
+
+// LoadOnce wraps a loader so it is called at most once per evaluation no matter
+// how many policies use it.
+func LoadOnce(load ResourceLoader) ResourceLoader {
+	key := &loadKey{}
+	return func(ctx context.Context) (proto.Message, error) {
+		cache, ok := ctx.Value(evaluationCacheKey{}).(*evaluationCache)
+		if !ok {
+			return load(ctx)
+		}
+		res := cache.result(key)
+		res.once.Do(func() {
+			res.msg, res.err = load(ctx)
+		})
+		return res.msg, res.err
+	}
+}
+
+// loadKey identifies a LoadOnce loader. It is not zero-sized so that each key
+// has a distinct address.
+type loadKey struct {
+	_ byte
+}
+
+type loadResult struct {
+	once sync.Once
+	msg  proto.Message
+	err  error
+}
+
+type evaluationCacheKey struct{}
+
+// evaluationCache holds values that are computed at most once per evaluation.
+type evaluationCache struct {
+	mu    sync.Mutex
+	loads map[*loadKey]*loadResult
+}
+
+func withEvaluationCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, evaluationCacheKey{}, &evaluationCache{
+		loads: make(map[*loadKey]*loadResult),
+	})
+}
+
+func (c *evaluationCache) result(key *loadKey) *loadResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res, ok := c.loads[key]
+	if !ok {
+		res = &loadResult{}
+		c.loads[key] = res
+	}
+	return res
+}
+
+// resourcePath maps a path in a request to the same field in a resource. The
+// resource is either the request message itself or the message type of one of
+// the fields along the path, e.g. "user.email" in an UpdateUserRequest is
+// "email" in a User.
+func resourcePath(request protoreflect.MessageDescriptor, path string, resource protoreflect.MessageDescriptor) (string, bool) {
+	desc := request
+	segments := strings.Split(path, ".")
+	for i, s := range segments {
+		if desc.FullName() == resource.FullName() {
+			return strings.Join(segments[i:], "."), true
+		}
+		fd := desc.Fields().ByName(protoreflect.Name(s))
+		if fd == nil {
+			fd = desc.Fields().ByJSONName(s)
+		}
+		if fd == nil || fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return "", false
+		}
+		desc = fd.Message()
+	}
+	return "", false
+}
+
+var _ Policy = (*immutablePolicy)(nil)
+
+// immutablePolicy fails when the field is in the mask with a value that differs
+// from the stored resource's. If once is set, a field may still be changed
+// while the stored value is zero.
+type immutablePolicy struct {
+	subject *fieldData
+	path    string
+	request protoreflect.MessageDescriptor
+	load    ResourceLoader
+	once    bool
+}
+
+func (ip *immutablePolicy) action() Action {
+	if ip.subject == nil || !ip.subject.m() {
+		return Skip
+	}
+	return Check
+}
+
+func (ip *immutablePolicy) Execute(ctx context.Context) error {
+	if ip.subject == nil || !ip.subject.m() {
+		return nil
+	}
+	return ip.EvaluateSubjectTraits(ctx)
+}
+
+func (ip *immutablePolicy) loadsResource() {}
+
+func (ip *immutablePolicy) EvaluateSubjectTraits(ctx context.Context) error {
+	if isDryRun(ctx) {
+		return nil
+	}
+	existing, err := ip.load(ctx)
+	if err != nil {
+		return wrapRuleError(MessageLoadFailed, err)
+	}
+	if existing == nil {
+		return nil
+	}
+	rp, ok := resourcePath(ip.request, ip.path, existing.ProtoReflect().Descriptor())
+	if !ok {
+		return fmt.Errorf("%s is not a field of %s", ip.path, existing.ProtoReflect().Descriptor().FullName())
+	}
+	stored := newFieldStore(existing).loadFieldsFromPath(rp).getByPath(rp)
+	if ip.once && (stored == nil || !stored.s() || stored.z()) {
+		return nil
+	}
+	if valuesEqual(comparableValue(ip.subject), comparableValue(stored)) {
+		return nil
+	}
+	if ip.once {
+		return newRuleError(MessageWriteOnce)
+	}
+	return newRuleError(MessageImmutable)
+}
@@ -0,0 +1,375 @@
+package propl
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const (
+	// resourceExtension is the field number of the google.api.resource extension of
+	// google.protobuf.MessageOptions, and of google.api.resource_definition of
+	// google.protobuf.FileOptions.
+	resourceExtension protowire.Number = 1053
+	// resourceReferenceExtension is the field number of the google.api.resource_reference
+	// extension of google.protobuf.FieldOptions.
+	resourceReferenceExtension protowire.Number = 1055
+)
+
+// ResourceName is a trait of string fields holding a resource name that matches one of
+// the patterns, e.g. "projects/{project}/users/{user}". Without patterns, they are read
+// from the field's annotations:
+//
+//   - a google.api.resource_reference with a type must match one of the patterns of the
+//     referenced resource, and one with a child_type must match the parent of one of
+//     the child's patterns. A type of "*" accepts any resource name.
+//   - the name field of a message annotated with google.api.resource must match one of
+//     the message's patterns.
+//
+// Referenced resources are looked up in the field's file and the global registry. Use
+// ResourceNameOf to read the segments of a name that has the trait.
+func ResourceName(patterns ...string) Trait {
+	return &trait{traitType: ResourceNamePattern, patterns: patterns}
+}
+
+// ParsedResourceName is a resource name matched against a pattern.
+type ParsedResourceName struct {
+	// Type is the resource type, e.g. "example.com/User", when the pattern was read
+	// from an annotation.
+	Type string
+	// Pattern is the pattern the name matched.
+	Pattern string
+	// Segments are the values of the pattern's variables, e.g. "project" and "user".
+	Segments map[string]string
+}
+
+// ParseResourceName matches the name against each pattern in turn and returns the first
+// match.
+func ParseResourceName(name string, patterns ...string) (ParsedResourceName, bool) {
+	for _, p := range patterns {
+		if segments, ok := matchResourcePattern(p, name); ok {
+			return ParsedResourceName{Pattern: p, Segments: segments}, true
+		}
+	}
+	return ParsedResourceName{}, false
+}
+
+// ResourceNameOf parses the resource name in the field at the path of msg using the
+// patterns from the field's annotations, as the ResourceName trait does.
+func ResourceNameOf(msg proto.Message, path string) (ParsedResourceName, bool) {
+	f := newFieldStore(msg).loadFieldsFromPath(path).getByPath(path)
+	if f == nil || f.d() == nil || !f.s() || f.d().Kind() != protoreflect.StringKind {
+		return ParsedResourceName{}, false
+	}
+	// a referenced resource that can't be found has no patterns to match
+	ref, _ := resourcePatternsOf(f.d())
+	return ref.parse(f.fv().String())
+}
+
+// resourcePatterns are the patterns a resource name field must match.
+type resourcePatterns struct {
+	// typ is the referenced resource type, if any
+	typ      string
+	patterns []string
+	// any accepts any resource name
+	any bool
+}
+
+func (rp resourcePatterns) parse(name string) (ParsedResourceName, bool) {
+	if rp.any {
+		if !validResourceName(name) {
+			return ParsedResourceName{}, false
+		}
+		return ParsedResourceName{Type: rp.typ, Segments: map[string]string{}}, true
+	}
+	parsed, ok := ParseResourceName(name, rp.patterns...)
+	parsed.Type = rp.typ
+	return parsed, ok
+}
+
+func (rp resourcePatterns) infraction() *RuleError {
+	switch {
+	case rp.any:
+		return newRuleError(MessageResourceName)
+	case rp.typ != "":
+		return newRuleError(MessageResourceNameType, "type", rp.typ)
+	case len(rp.patterns) > 0:
+		return newRuleError(MessageResourceNamePattern, "patterns", strings.Join(rp.patterns, " or "))
+	default:
+		return newRuleError(MessageResourceNameUndeclared)
+	}
+}
+
+// matchResourcePattern matches a name such as "projects/p1/users/u1" against a pattern
+// such as "projects/{project}/users/{user}". Each variable matches one non-empty segment.
+func matchResourcePattern(pattern, name string) (map[string]string, bool) {
+	ps, ns := strings.Split(pattern, "/"), strings.Split(name, "/")
+	if len(ps) != len(ns) {
+		return nil, false
+	}
+	segments := make(map[string]string)
+	for i, p := range ps {
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			if ns[i] == "" {
+				return nil, false
+			}
+			segments[p[1:len(p)-1]] = ns[i]
+			continue
+		}
+		if p != ns[i] {
+			return nil, false
+		}
+	}
+	return segments, true
+}
+
+// validResourceName reports whether the name is made of non-empty segments.
+func validResourceName(name string) bool {
+	for _, s := range strings.Split(name, "/") {
+		if s == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parentPattern drops the last collection and variable of a pattern, e.g.
+// "projects/{project}/users/{user}" becomes "projects/{project}".
+func parentPattern(pattern string) (string, bool) {
+	ps := strings.Split(pattern, "/")
+	if len(ps) < 4 {
+		return "", false
+	}
+	return strings.Join(ps[:len(ps)-2], "/"), true
+}
+
+// resolveResourceNames copies the trait chain with the patterns of its resource name
+// traits resolved, so the field's annotations are read once, when the policy is
+// declared. A reference to a resource type that can't be found is reported by Err.
+// Traits declared for a nil message when T is an interface are resolved when bound.
+func (r *Propl[T]) resolveResourceNames(path string, t Trait) Trait {
+	ct, ok := t.(*trait)
+	if !ok || ct == nil || !ct.hasResourceName() {
+		return t
+	}
+	desc, err := r.descriptor()
+	if err != nil {
+		return t
+	}
+	var rp resourcePatterns
+	if fd := fieldByPath(desc, path); fd != nil && ct.readsAnnotations() {
+		if rp, err = resourcePatternsOf(fd); err != nil {
+			r.errs = append(r.errs, fmt.Errorf("invalid resource name policy for %s: %w", path, err))
+		}
+	}
+	return resolveTrait(ct, rp)
+}
+
+// resolveTrait copies the chain, setting the patterns of each resource name trait to
+// its declared patterns, or to rp, the patterns from the field's annotations.
+func resolveTrait(t *trait, rp resourcePatterns) *trait {
+	if t == nil {
+		return nil
+	}
+	resolved := *t
+	if resolved.traitType == ResourceNamePattern {
+		resolved.resource, resolved.resolved = rp, true
+		if len(resolved.patterns) > 0 {
+			resolved.resource = resourcePatterns{patterns: resolved.patterns}
+		}
+	}
+	resolved.andTrait = resolveTrait(t.andTrait, rp)
+	resolved.orTrait = resolveTrait(t.orTrait, rp)
+	return &resolved
+}
+
+// resourcePatternsOf reads the patterns for the field from its annotations. It fails
+// when the field references a resource type that can't be found.
+func resourcePatternsOf(fd protoreflect.FieldDescriptor) (resourcePatterns, error) {
+	if typ, childType, ok := resourceReference(fd); ok {
+		return referencedPatterns(fd.ParentFile(), typ, childType)
+	}
+	if rd, ok := messageResource(fd.ContainingMessage()); ok && rd.nameField() == string(fd.Name()) {
+		return resourcePatterns{typ: rd.typ, patterns: rd.patterns}, nil
+	}
+	return resourcePatterns{}, nil
+}
+
+func referencedPatterns(file protoreflect.FileDescriptor, typ, childType string) (resourcePatterns, error) {
+	if typ == "*" || childType == "*" {
+		return resourcePatterns{any: true}, nil
+	}
+	if typ != "" {
+		rd, ok := findResource(file, typ)
+		if !ok {
+			return resourcePatterns{typ: typ}, fmt.Errorf("resource type %s is not declared", typ)
+		}
+		return resourcePatterns{typ: typ, patterns: rd.patterns}, nil
+	}
+	rd, ok := findResource(file, childType)
+	if !ok {
+		return resourcePatterns{}, fmt.Errorf("child resource type %s is not declared", childType)
+	}
+	var patterns []string
+	for _, p := range rd.patterns {
+		if parent, ok := parentPattern(p); ok {
+			patterns = append(patterns, parent)
+		}
+	}
+	return resourcePatterns{patterns: patterns}, nil
+}
+
+// resourceDescriptor mirrors the google.api.ResourceDescriptor fields propl uses.
+type resourceDescriptor struct {
+	typ           string
+	patterns      []string
+	nameFieldName string
+}
+
+func (rd resourceDescriptor) nameField() string {
+	if rd.nameFieldName == "" {
+		return "name"
+	}
+	return rd.nameFieldName
+}
+
+// findResource looks up the resource type in the file, then in the global registry.
+func findResource(file protoreflect.FileDescriptor, typ string) (resourceDescriptor, bool) {
+	if file != nil {
+		if rd, ok := fileResource(file, typ); ok {
+			return rd, true
+		}
+	}
+	var found resourceDescriptor
+	var ok bool
+	protoregistry.GlobalFiles.RangeFiles(func(f protoreflect.FileDescriptor) bool {
+		found, ok = fileResource(f, typ)
+		return !ok
+	})
+	return found, ok
+}
+
+func fileResource(file protoreflect.FileDescriptor, typ string) (resourceDescriptor, bool) {
+	for _, b := range extensionBytes(file.Options(), resourceExtension) {
+		if rd := parseResourceDescriptor(b); rd.typ == typ {
+			return rd, true
+		}
+	}
+	return messagesResource(file.Messages(), typ)
+}
+
+func messagesResource(msgs protoreflect.MessageDescriptors, typ string) (resourceDescriptor, bool) {
+	for i := 0; i < msgs.Len(); i++ {
+		if rd, ok := messageResource(msgs.Get(i)); ok && rd.typ == typ {
+			return rd, true
+		}
+		if rd, ok := messagesResource(msgs.Get(i).Messages(), typ); ok {
+			return rd, true
+		}
+	}
+	return resourceDescriptor{}, false
+}
+
+// messageResource reads the google.api.resource annotation of the message.
+func messageResource(md protoreflect.MessageDescriptor) (resourceDescriptor, bool) {
+	if md == nil {
+		return resourceDescriptor{}, false
+	}
+	b := extensionBytes(md.Options(), resourceExtension)
+	if len(b) == 0 {
+		return resourceDescriptor{}, false
+	}
+	return parseResourceDescriptor(b[len(b)-1]), true
+}
+
+// resourceReference reads the google.api.resource_reference annotation of the field.
+func resourceReference(fd protoreflect.FieldDescriptor) (typ, childType string, ok bool) {
+	b := extensionBytes(fd.Options(), resourceReferenceExtension)
+	if len(b) == 0 {
+		return "", "", false
+	}
+	fields := stringFields(b[len(b)-1])
+	return last(fields[1]), last(fields[2]), true
+}
+
+func parseResourceDescriptor(b []byte) resourceDescriptor {
+	fields := stringFields(b)
+	return resourceDescriptor{
+		typ:           last(fields[1]),
+		patterns:      fields[2],
+		nameFieldName: last(fields[3]),
+	}
+}
+
+func last(s []string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[len(s)-1]
+}
+
+// extensionBytes returns the values of the length-delimited extension in the options,
+// whether or not the extension is registered.
+func extensionBytes(opts proto.Message, num protowire.Number) [][]byte {
+	if opts == nil {
+		return nil
+	}
+	b, err := proto.Marshal(opts)
+	if err != nil {
+		return nil
+	}
+	var values [][]byte
+	for len(b) > 0 {
+		n, typ, tn := protowire.ConsumeTag(b)
+		if tn < 0 {
+			return values
+		}
+		b = b[tn:]
+		if n == num && typ == protowire.BytesType {
+			v, vn := protowire.ConsumeBytes(b)
+			if vn < 0 {
+				return values
+			}
+			values = append(values, v)
+			b = b[vn:]
+			continue
+		}
+		vn := protowire.ConsumeFieldValue(n, typ, b)
+		if vn < 0 {
+			return values
+		}
+		b = b[vn:]
+	}
+	return values
+}
+
+// stringFields reads the length-delimited fields of an encoded message as strings.
+func stringFields(b []byte) map[protowire.Number][]string {
+	fields := make(map[protowire.Number][]string)
+	for len(b) > 0 {
+		n, typ, tn := protowire.ConsumeTag(b)
+		if tn < 0 {
+			return fields
+		}
+		b = b[tn:]
+		if typ == protowire.BytesType {
+			v, vn := protowire.ConsumeBytes(b)
+			if vn < 0 {
+				return fields
+			}
+			fields[n] = append(fields[n], string(v))
+			b = b[vn:]
+			continue
+		}
+		vn := protowire.ConsumeFieldValue(n, typ, b)
+		if vn < 0 {
+			return fields
+		}
+		b = b[vn:]
+	}
+	return fields
+}
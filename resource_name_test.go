@@ -0,0 +1,88 @@
+package propl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestResourceName(t *testing.T) {
+	shelves := testShelves(t)
+
+	t.Run("it should match the referenced resource's patterns", func(t *testing.T) {
+		// arrange
+		valid := newTestMessage(shelves, "GetBookRequest")
+		setTestField(valid, []protoreflect.Name{"name"}, protoreflect.ValueOfString("shelves/s1/books/b1"))
+		invalid := newTestMessage(shelves, "GetBookRequest")
+		setTestField(invalid, []protoreflect.Name{"name"}, protoreflect.ValueOfString("shelves/s1"))
+		// act
+		validErr := For(valid).ResourceName("name").E(context.Background())
+		res, err := For(invalid).ResourceName("name").Check(context.Background())
+		// assert
+		assert.NoError(t, validErr)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"name"}, res.Infractions.Paths())
+		assert.EqualError(t, res.Infractions[0].Err, "it should be a library.example.com/Book resource name")
+	})
+
+	t.Run("it should match the parent of a child type", func(t *testing.T) {
+		// arrange
+		req := newTestMessage(shelves, "CreateBookRequest")
+		setTestField(req, []protoreflect.Name{"parent"}, protoreflect.ValueOfString("shelves/s1"))
+		setTestField(req, []protoreflect.Name{"book", "name"}, protoreflect.ValueOfString("shelves/s1/b1"))
+		p := For(req).
+			ResourceName("parent").
+			ResourceName("book.name")
+		// act
+		res, err := p.Check(context.Background())
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"book.name"}, res.Infractions.Paths())
+	})
+
+	t.Run("it should accept explicit patterns and any name for a wildcard reference", func(t *testing.T) {
+		// arrange
+		req := newTestMessage(shelves, "GetBookRequest")
+		setTestField(req, []protoreflect.Name{"name"}, protoreflect.ValueOfString("shelves/s1"))
+		setTestField(req, []protoreflect.Name{"any"}, protoreflect.ValueOfString("publishers/p1"))
+		p := For(req).
+			FieldPolicy("name", ResourceName("shelves/{shelf}"), InMessage).
+			ResourceName("any")
+		// act
+		err := p.E(context.Background())
+		// assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should report a reference to an undeclared resource type", func(t *testing.T) {
+		// arrange
+		req := newTestMessage(shelves, "GetBookRequest")
+		p := For(req).
+			ResourceName("publisher").
+			FieldPolicy("publisher", ResourceName("publishers/{publisher}"), InMessage)
+		// act
+		err := p.Err()
+		// assert
+		assert.EqualError(t, err, "invalid resource name policy for publisher: "+
+			"resource type library.example.com/Publisher is not declared")
+	})
+
+	t.Run("it should expose the parsed segments", func(t *testing.T) {
+		// arrange
+		req := newTestMessage(shelves, "GetBookRequest")
+		setTestField(req, []protoreflect.Name{"name"}, protoreflect.ValueOfString("shelves/s1/books/b1"))
+		// act
+		name, ok := ResourceNameOf(req, "name")
+		_, explicitOK := ParseResourceName("shelves/s1/books/", "shelves/{shelf}/books/{book}")
+		// assert
+		assert.True(t, ok)
+		assert.False(t, explicitOK)
+		assert.Equal(t, ParsedResourceName{
+			Type:     "library.example.com/Book",
+			Pattern:  "shelves/{shelf}/books/{book}",
+			Segments: map[string]string{"shelf": "s1", "book": "b1"},
+		}, name)
+	})
+}
@@ -0,0 +1,14 @@
+package propl
+
+// Result is the outcome of evaluating a policy set.
+type Result struct {
+	// Infractions with SeverityError, which fail evaluation.
+	Infractions FieldInfractions
+	// Warnings are the infractions with a severity below SeverityError.
+	Warnings FieldInfractions
+}
+
+// Failed reports whether any infraction fails evaluation.
+func (r *Result) Failed() bool {
+	return len(r.Infractions) > 0
+}
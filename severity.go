@@ -0,0 +1,24 @@
+package propl
+
+// Severity of an infraction. Only SeverityError fails evaluation, which makes
+// it possible to roll out a new policy softly by declaring it as a warning first.
+type Severity uint32
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	default:
+		return "unknown"
+	}
+}
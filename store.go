@@ -11,6 +11,7 @@ import (
 
 type fieldStore[T proto.Message] struct {
 	msg            T
+	maskPaths      []string
 	maskPathLookup map[string]struct{}
 	store          map[string]*fieldData
 }
@@ -23,6 +24,7 @@ func newFieldStore[T proto.Message](msg T, maskPaths ...string) *fieldStore[T] {
 	return &fieldStore[T]{
 		msg:            msg,
 		store:          make(map[string]*fieldData),
+		maskPaths:      maskPaths,
 		maskPathLookup: pathLookup,
 	}
 }
@@ -31,6 +33,10 @@ func (f fieldStore[T]) message() T {
 	return f.msg
 }
 
+func (f fieldStore[T]) mask() []string {
+	return f.maskPaths
+}
+
 func (f fieldStore[T]) isFieldInMask(p string) bool {
 	if _, im := f.maskPathLookup[p]; im {
 		return im
@@ -72,11 +78,24 @@ type fieldData struct {
 	inMask bool
 	set    bool
 	value  protoreflect.Value
+	// desc is nil if the path does not resolve to a field
+	desc protoreflect.FieldDescriptor
 }
 
 // HasTrait implements policy.Subject.
 func (f *fieldData) HasTrait(t Trait) bool {
-	return t.Type() == NotZero && f.z()
+	switch t.Type() {
+	case NotZero:
+		return !f.z()
+	case FieldEqual, FieldNotEqual, FieldLessThan, FieldGreaterThan:
+		ct, ok := t.(*trait)
+		return ok && ct.compare(f)
+	case ResourceNamePattern:
+		ct, ok := t.(*trait)
+		return ok && ct.matchesResourceName(f)
+	default:
+		return false
+	}
 }
 
 // ConditionalAction implements policy.Subject.
@@ -93,17 +112,19 @@ func (f *fieldData) ConditionalAction(conditions Condition) Action {
 	return Check
 }
 
-func newFieldData(fv protoreflect.Value, inMask bool, path string) *fieldData {
+func newFieldData(fv protoreflect.Value, desc protoreflect.FieldDescriptor, inMask bool, path string) *fieldData {
 	return &fieldData{
 		value:  fv,
+		desc:   desc,
 		path:   path,
 		inMask: inMask,
 		set:    true,
 	}
 }
 
-func newUnsetFieldData(inMask bool, path string) *fieldData {
+func newUnsetFieldData(desc protoreflect.FieldDescriptor, inMask bool, path string) *fieldData {
 	return &fieldData{
+		desc:   desc,
 		path:   path,
 		inMask: inMask,
 	}
@@ -129,6 +150,11 @@ func (f fieldData) p() string {
 	return f.path
 }
 
+// d returns the field's descriptor, or nil if the path does not resolve to a field.
+func (f fieldData) d() protoreflect.FieldDescriptor {
+	return f.desc
+}
+
 func (f fieldData) s() bool {
 	return f.set
 }
@@ -149,6 +175,7 @@ func (store *fieldStore[T]) loadFieldsFromPathRecursive(message proto.Message, d
 	var (
 		fieldValue protoreflect.Value
 		set        bool
+		f          protoreflect.FieldDescriptor
 	)
 	existing := store.getByPath(topLevelParent)
 	if existing != nil {
@@ -158,13 +185,13 @@ func (store *fieldStore[T]) loadFieldsFromPathRecursive(message proto.Message, d
 		fieldValue = existing.fv()
 		set = existing.s()
 	} else {
-		f := desc.Fields().ByName(protoreflect.Name(topLevelParent))
+		f = desc.Fields().ByName(protoreflect.Name(topLevelParent))
 		if f == nil {
 			f = desc.Fields().ByJSONName(topLevelParent)
 		}
 		if f == nil {
 			for i := range spl {
-				store.add(newUnsetFieldData(inMask, getPath(traversed, strings.Join(spl[0:i+1], "."))))
+				store.add(newUnsetFieldData(nil, inMask, getPath(traversed, strings.Join(spl[0:i+1], "."))))
 			}
 			return
 		}
@@ -173,18 +200,21 @@ func (store *fieldStore[T]) loadFieldsFromPathRecursive(message proto.Message, d
 	}
 	if len(spl) == 1 {
 		if !set {
-			store.add(newUnsetFieldData(inMask, getPath(traversed, topLevelParent)))
+			store.add(newUnsetFieldData(f, inMask, getPath(traversed, topLevelParent)))
 			return
 		}
-		store.add(newFieldData(fieldValue, inMask, getPath(traversed, topLevelParent)))
+		store.add(newFieldData(fieldValue, f, inMask, getPath(traversed, topLevelParent)))
 		return
 	}
-	if fieldValue.Message() == nil {
+	// the parent may be unknown or not a message, e.g. when the path
+	// is loaded again
+	parent, ok := fieldValue.Interface().(protoreflect.Message)
+	if !fieldValue.IsValid() || !ok || parent == nil {
 		return
 	}
 	store.loadFieldsFromPathRecursive(
-		fieldValue.Message().Interface(),
-		fieldValue.Message().Descriptor(),
+		parent.Interface(),
+		parent.Descriptor(),
 		inMask,
 		strings.Join(spl[1:], "."),
 		getPath(traversed, topLevelParent))
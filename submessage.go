@@ -0,0 +1,285 @@
+package propl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// SubMessagePolicies evaluates the policy set against every occurrence of the named
+// message type in the message, at any depth: in singular fields, and in the elements
+// of repeated fields and the values of maps. This declares the rules of a type such
+// as an Address once rather than for each path it appears on:
+//
+//	SubMessagePolicies("propl.v1.Address", For[*v1.Address](nil).NeverZero("line1"))
+//
+// Infractions are reported on paths relative to the message, with the index of
+// repeated fields and the key of maps in brackets, e.g.
+// "user.secondary_addresses[1].line1". The policy set is evaluated with the mask
+// paths relative to each occurrence.
+//
+// The defaults and normalizers of the policy set are applied to each occurrence
+// before any policy is evaluated, as the set's own are, so that evaluating the
+// occurrences doesn't modify the message while other policies read it. A PolicySet
+// other than a Propl is evaluated with CheckMessage as is.
+//
+// Recursive types are walked as deep as the message is set. A policy set that itself
+// declares SubMessagePolicies for the same type does not apply them again within an
+// occurrence, since every occurrence is already evaluated.
+func (r *Propl[T]) SubMessagePolicies(name protoreflect.FullName, set PolicySet, opts ...PolicyOption) *Propl[T] {
+	sub := &subMessages{set: set, types: &subMessageTypes{name: name}}
+	r.subMessages = append(r.subMessages, sub)
+	// like custom evals, sets can't be compared
+	r.addPolicy(string(name), "SubMessagePolicies", "", func(store *fieldStore[T]) Policy {
+		return &subMessagePolicy{
+			subMessages: sub,
+			root:        store.message().ProtoReflect(),
+			maskPaths:   store.mask(),
+		}
+	}, opts)
+	return r
+}
+
+// subMessages is a policy set declared for the occurrences of a message type.
+type subMessages struct {
+	set   PolicySet
+	types *subMessageTypes
+}
+
+// preparedPolicySet is implemented by policy sets whose defaults and normalizers
+// can be applied to an occurrence before the message is evaluated.
+type preparedPolicySet interface {
+	PolicySet
+	prepareMessage(ctx context.Context, msg proto.Message, maskPaths []string)
+	checkPrepared(ctx context.Context, msg proto.Message, maskPaths []string) (*Result, error)
+}
+
+var _ preparedPolicySet = (*Propl[proto.Message])(nil)
+
+// prepare applies the defaults and normalizers to msg, and to the occurrences of
+// each sub-message type.
+func (r *Propl[T]) prepare(ctx context.Context, msg T, maskPaths []string) {
+	r.applyDefaults(msg, maskPaths)
+	r.normalize(msg, maskPaths)
+	if len(r.subMessages) == 0 || isNilMessage(msg) {
+		return
+	}
+	root := msg.ProtoReflect()
+	for _, sub := range r.subMessages {
+		set, ok := sub.set.(preparedPolicySet)
+		if !ok || appliesSubMessages(ctx, sub.types.name) {
+			continue
+		}
+		subCtx := withSubMessages(ctx, sub.types.name)
+		for _, o := range sub.occurrences(root) {
+			set.prepareMessage(subCtx, o.msg.Interface(), relativeMaskPaths(o.fieldPath, maskPaths, o.msg.Descriptor()))
+		}
+	}
+}
+
+func (r *Propl[T]) prepareMessage(ctx context.Context, msg proto.Message, maskPaths []string) {
+	// a message of another type is reported when it's checked
+	if t, err := r.assertMessage(msg); err == nil {
+		r.prepare(ctx, t, maskPaths)
+	}
+}
+
+func (r *Propl[T]) checkPrepared(ctx context.Context, msg proto.Message, maskPaths []string) (*Result, error) {
+	t, err := r.assertMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+	return r.check(ctx, t, maskPaths, true, nil)
+}
+
+var _ Policy = (*subMessagePolicy)(nil)
+
+// subMessagePolicy evaluates a policy set against each occurrence of a message type.
+type subMessagePolicy struct {
+	*subMessages
+	root      protoreflect.Message
+	maskPaths []string
+}
+
+func (sp *subMessagePolicy) Execute(ctx context.Context) error {
+	return sp.EvaluateSubjectTraits(ctx)
+}
+
+func (sp *subMessagePolicy) EvaluateSubjectTraits(ctx context.Context) error {
+	if !sp.root.IsValid() || appliesSubMessages(ctx, sp.types.name) {
+		return nil
+	}
+	ctx = withSubMessages(ctx, sp.types.name)
+	res := &Result{}
+	for _, o := range sp.occurrences(sp.root) {
+		maskPaths := relativeMaskPaths(o.fieldPath, sp.maskPaths, o.msg.Descriptor())
+		var sub *Result
+		var err error
+		if set, ok := sp.set.(preparedPolicySet); ok {
+			sub, err = set.checkPrepared(ctx, o.msg.Interface(), maskPaths)
+		} else {
+			sub, err = sp.set.CheckMessage(ctx, o.msg.Interface(), maskPaths...)
+		}
+		if err != nil {
+			return err
+		}
+		res.Infractions = append(res.Infractions, prefixInfractions(o.path, sub.Infractions)...)
+		res.Warnings = append(res.Warnings, prefixInfractions(o.path, sub.Warnings)...)
+	}
+	if len(res.Infractions) == 0 && len(res.Warnings) == 0 {
+		return nil
+	}
+	return &nestedInfractions{result: res}
+}
+
+// occurrence is a sub-message of the type, at path. fieldPath is the path without
+// indexes or keys, as it appears in a mask.
+type occurrence struct {
+	msg       protoreflect.Message
+	path      string
+	fieldPath string
+}
+
+// occurrences returns the occurrences of the type in root.
+func (sm *subMessages) occurrences(root protoreflect.Message) []occurrence {
+	var occurrences []occurrence
+	sm.find(root.Descriptor(), root, "", "", make(map[protoreflect.Message]bool), &occurrences)
+	return occurrences
+}
+
+// find collects the occurrences in the set fields of m, in field order. Fields whose
+// type can't contain the message type are not walked, and each message is visited
+// once in case a message is referenced more than once.
+func (sm *subMessages) find(root protoreflect.MessageDescriptor, m protoreflect.Message, path, fieldPath string, visited map[protoreflect.Message]bool, occurrences *[]occurrence) {
+	if visited[m] {
+		return
+	}
+	visited[m] = true
+	reachable := sm.types.reachable(root)
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		md := fd.Message()
+		if fd.IsMap() {
+			md = fd.MapValue().Message()
+		}
+		if md == nil || !reachable[md.FullName()] || !m.Has(fd) {
+			continue
+		}
+		name := string(fd.Name())
+		visit := func(v protoreflect.Message, elem string) {
+			p, fp := getPath(path, name+elem), getPath(fieldPath, name)
+			if md.FullName() == sm.types.name {
+				*occurrences = append(*occurrences, occurrence{msg: v, path: p, fieldPath: fp})
+			}
+			sm.find(root, v, p, fp, visited, occurrences)
+		}
+		v := m.Get(fd)
+		switch {
+		case fd.IsList():
+			for j := 0; j < v.List().Len(); j++ {
+				visit(v.List().Get(j).Message(), fmt.Sprintf("[%d]", j))
+			}
+		case fd.IsMap():
+			for _, k := range sortedMapKeys(v.Map()) {
+				visit(v.Map().Get(k).Message(), fmt.Sprintf("[%v]", k.Interface()))
+			}
+		default:
+			visit(v.Message(), "")
+		}
+	}
+}
+
+// subMessageTypes caches, for each root message type, the message types that are
+// the sub-message type or can contain it.
+type subMessageTypes struct {
+	name  protoreflect.FullName
+	cache sync.Map
+}
+
+// reachable returns the message types, reachable from root, that are the
+// sub-message type or have a field whose type is reachable. Recursive types are
+// visited once.
+func (st *subMessageTypes) reachable(root protoreflect.MessageDescriptor) map[protoreflect.FullName]bool {
+	if r, ok := st.cache.Load(root.FullName()); ok {
+		return r.(map[protoreflect.FullName]bool)
+	}
+	// containers maps each message type to the types with a field of its type
+	containers := make(map[protoreflect.FullName][]protoreflect.FullName)
+	seen := map[protoreflect.FullName]bool{root.FullName(): true}
+	queue := []protoreflect.MessageDescriptor{root}
+	for len(queue) > 0 {
+		md := queue[0]
+		queue = queue[1:]
+		fields := md.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			sub := fd.Message()
+			if fd.IsMap() {
+				sub = fd.MapValue().Message()
+			}
+			if sub == nil {
+				continue
+			}
+			containers[sub.FullName()] = append(containers[sub.FullName()], md.FullName())
+			if !seen[sub.FullName()] {
+				seen[sub.FullName()] = true
+				queue = append(queue, sub)
+			}
+		}
+	}
+	reachable := map[protoreflect.FullName]bool{st.name: true}
+	names := []protoreflect.FullName{st.name}
+	for len(names) > 0 {
+		name := names[0]
+		names = names[1:]
+		for _, c := range containers[name] {
+			if !reachable[c] {
+				reachable[c] = true
+				names = append(names, c)
+			}
+		}
+	}
+	st.cache.Store(root.FullName(), reachable)
+	return reachable
+}
+
+// sortedMapKeys returns the map's keys in order, so occurrences are reported in the
+// same order each evaluation.
+func sortedMapKeys(m protoreflect.Map) []protoreflect.MapKey {
+	keys := make([]protoreflect.MapKey, 0, m.Len())
+	m.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool {
+		c, _ := compareValues(keys[i].Value(), keys[j].Value())
+		return c < 0
+	})
+	return keys
+}
+
+type subMessagesKey struct{}
+
+// withSubMessages returns a context in which the sub-message policies of the type
+// are being applied.
+func withSubMessages(ctx context.Context, name protoreflect.FullName) context.Context {
+	applying, _ := ctx.Value(subMessagesKey{}).(map[protoreflect.FullName]bool)
+	next := make(map[protoreflect.FullName]bool, len(applying)+1)
+	for n := range applying {
+		next[n] = true
+	}
+	next[name] = true
+	return context.WithValue(ctx, subMessagesKey{}, next)
+}
+
+// appliesSubMessages reports whether the sub-message policies of the type are
+// already being applied in ctx.
+func appliesSubMessages(ctx context.Context, name protoreflect.FullName) bool {
+	applying, _ := ctx.Value(subMessagesKey{}).(map[protoreflect.FullName]bool)
+	return applying[name]
+}
@@ -2,6 +2,10 @@ package propl
 
 import (
 	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 type TraitType uint32
@@ -9,6 +13,11 @@ type TraitType uint32
 const (
 	NotZero TraitType = iota
 	NotEqual
+	FieldEqual
+	FieldNotEqual
+	FieldLessThan
+	FieldGreaterThan
+	ResourceNamePattern
 )
 
 var _ Trait = (*trait)(nil)
@@ -18,6 +27,39 @@ type trait struct {
 	traitType TraitType
 	andTrait  *trait
 	orTrait   *trait
+	// path of the field the trait is checked on
+	path string
+	// otherPath of the field compared against, for comparison traits
+	otherPath string
+	other     *fieldData
+	// patterns declared for resource name traits, and the patterns the trait
+	// checks once bound
+	patterns []string
+	resource resourcePatterns
+	// resolved is set when resource was resolved as the policy was declared
+	resolved bool
+}
+
+// EqualsField is a trait of fields equal to the field at otherPath in the same message.
+func EqualsField(otherPath string) Trait {
+	return &trait{traitType: FieldEqual, otherPath: otherPath}
+}
+
+// NotEqualsField is a trait of fields not equal to the field at otherPath.
+func NotEqualsField(otherPath string) Trait {
+	return &trait{traitType: FieldNotEqual, otherPath: otherPath}
+}
+
+// LessThanField is a trait of fields less than the field at otherPath. Scalars,
+// enums, google.protobuf.Timestamp, google.protobuf.Duration and the wrapper types
+// can be ordered.
+func LessThanField(otherPath string) Trait {
+	return &trait{traitType: FieldLessThan, otherPath: otherPath}
+}
+
+// GreaterThanField is a trait of fields greater than the field at otherPath.
+func GreaterThanField(otherPath string) Trait {
+	return &trait{traitType: FieldGreaterThan, otherPath: otherPath}
 }
 
 func (t *trait) and(and *trait) *trait {
@@ -47,5 +89,124 @@ func (t *trait) or(or *trait) *trait {
 }
 
 func (t *trait) InfractionsString() string {
-	return fmt.Sprintf("it should not be zero")
+	return t.infraction().Error()
+}
+
+// infraction is the error reported when a subject does not have the trait.
+func (t *trait) infraction() *RuleError {
+	switch t.traitType {
+	case FieldEqual:
+		return newRuleError(MessageFieldEqual, "path", t.path, "other", t.otherPath)
+	case FieldNotEqual:
+		return newRuleError(MessageFieldNotEqual, "path", t.path, "other", t.otherPath)
+	case FieldLessThan:
+		return newRuleError(MessageFieldLessThan, "path", t.path, "other", t.otherPath)
+	case FieldGreaterThan:
+		return newRuleError(MessageFieldGreaterThan, "path", t.path, "other", t.otherPath)
+	case ResourceNamePattern:
+		return t.resource.infraction()
+	default:
+		return newRuleError(MessageNotZero)
+	}
+}
+
+// compare reports whether the subject compares to the other field as the
+// trait requires. Fields that are not set compare as their default value, or
+// as absent for messages. Absent values are only equal to each other and
+// can't be ordered.
+func (t *trait) compare(subject *fieldData) bool {
+	a, b := comparableValue(subject), comparableValue(t.other)
+	switch t.traitType {
+	case FieldEqual:
+		return valuesEqual(a, b)
+	case FieldNotEqual:
+		return !valuesEqual(a, b)
+	case FieldLessThan:
+		c, ok := compareValues(a, b)
+		return ok && c < 0
+	case FieldGreaterThan:
+		c, ok := compareValues(a, b)
+		return ok && c > 0
+	default:
+		return false
+	}
+}
+
+// matchesResourceName reports whether the subject holds a resource name matching
+// the bound patterns.
+func (t *trait) matchesResourceName(subject *fieldData) bool {
+	if !subject.s() || subject.d() == nil || subject.d().Kind() != protoreflect.StringKind {
+		return false
+	}
+	_, ok := t.resource.parse(subject.fv().String())
+	return ok
+}
+
+// traitRule names a policy by the type of the first trait it checks.
+func traitRule(t Trait) string {
+	if t == nil || !t.Valid() {
+		return ""
+	}
+	return t.Type().String()
+}
+
+// traitIdentity describes the trait chain starting at t.
+func traitIdentity(t Trait) string {
+	if t == nil || !t.Valid() {
+		return ""
+	}
+	var other string
+	if ct, ok := t.(*trait); ok {
+		other = ct.otherPath + strings.Join(ct.patterns, " ")
+	}
+	return fmt.Sprintf("(%d %s and %s or %s)", t.Type(), other, traitIdentity(t.And()), traitIdentity(t.Or()))
+}
+
+// hasResourceName reports whether the chain starting at t has a resource name trait.
+func (t *trait) hasResourceName() bool {
+	return t != nil && (t.traitType == ResourceNamePattern || t.andTrait.hasResourceName() || t.orTrait.hasResourceName())
+}
+
+// readsAnnotations reports whether the chain starting at t has a resource name trait
+// without declared patterns, which reads them from the field's annotations.
+func (t *trait) readsAnnotations() bool {
+	if t == nil {
+		return false
+	}
+	if t.traitType == ResourceNamePattern && len(t.patterns) == 0 {
+		return true
+	}
+	return t.andTrait.readsAnnotations() || t.orTrait.readsAnnotations()
+}
+
+// bindTraits copies the trait chain, resolving the fields each trait references
+// from the store so the same traits can be declared on more than one path.
+func bindTraits[T proto.Message](store *fieldStore[T], path string, t Trait) Trait {
+	ct, ok := t.(*trait)
+	if !ok || ct == nil {
+		return t
+	}
+	return bindTrait(store, path, ct)
+}
+
+func bindTrait[T proto.Message](store *fieldStore[T], path string, t *trait) *trait {
+	if t == nil {
+		return nil
+	}
+	bound := *t
+	bound.path = path
+	if bound.otherPath != "" {
+		bound.other = store.loadFieldsFromPath(bound.otherPath).getByPath(bound.otherPath)
+	}
+	if bound.traitType == ResourceNamePattern && !bound.resolved {
+		bound.resource = resourcePatterns{patterns: bound.patterns}
+		if len(bound.patterns) == 0 {
+			if subject := store.loadFieldsFromPath(path).getByPath(path); subject != nil && subject.d() != nil {
+				bound.resource, _ = resourcePatternsOf(subject.d())
+			}
+		}
+	}
+	bound.andTrait = bindTrait(store, path, t.andTrait)
+	bound.orTrait = bindTrait(store, path, t.orTrait)
+	return &bound
 }